@@ -7,13 +7,18 @@ import (
 	"errors"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httputil"
-	"os"
+	"time"
 )
 
 type nexusApi struct {
 	*http.Client
+	retry    *retryPolicy
+	timeouts *httpTimeouts
+
+	*session // all requests are cancelled with the session context
 }
 
 var (
@@ -22,17 +27,28 @@ var (
 	nxsErrRq404       = errors.New("Could not complete the request because of Nexus api respond 404 error!")
 )
 
-func newNexusApi() *nexusApi {
+// newNexusApi returns the api client with limits of the given endpoint role (src or dst)
+func newNexusApi(s *session, role string) *nexusApi {
+	var timeouts = newHttpTimeouts(s.opts, role)
+
 	return &nexusApi{
 		Client: &http.Client{
-			Timeout: gCli.Duration("http-client-timeout"),
-			Transport: &http.Transport{
+			Transport: newLimitedTransport(&http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   timeouts.connect,
+					KeepAlive: 30 * time.Second,
+				}).DialContext,
+				TLSHandshakeTimeout:   timeouts.tls,
+				ResponseHeaderTimeout: timeouts.header,
 				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: gCli.Bool("http-client-insecure"),
+					InsecureSkipVerify: s.opts.HTTPClientInsecure,
 				},
 				DisableCompression: false,
-			},
+			}, s, role),
 		},
+		retry:    newRetryPolicy(s.opts),
+		timeouts: timeouts,
+		session:  s,
 	}
 }
 
@@ -47,66 +63,171 @@ func (m *nexusApi) authorizeNexusRequest(r *http.Request) {
 
 func (m *nexusApi) getNexusRequest(url string, rspJsonSchema interface{}) (e error) {
 	var req *http.Request
-	if req, e = http.NewRequest("GET", url, nil); e != nil {
+	if req, e = http.NewRequestWithContext(m.ctx, "GET", url, nil); e != nil {
 		return
 	}
 
 	m.authorizeNexusRequest(req)
-	gLog.Debug().Str("url", url).Msg("trying to make api request")
+	m.log.Debug().Str("url", url).Msg("trying to make api request")
 
 	var rsp *http.Response
-	if rsp, e = m.Client.Do(req); e != nil {
-		gLog.Warn().Msg("Could not get requested URL!")
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		m.log.Warn().Msg("Could not get requested URL!")
 		return
 	}
 
+	defer rsp.Body.Close()
+
 	if rsp.StatusCode != http.StatusOK {
-		gLog.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
 		return nxsErrRq404
 	}
 
-	defer rsp.Body.Close()
 	return m.parseNexusResponse(&rsp.Body, rspJsonSchema)
 }
 
-func (m *nexusApi) parseNexusResponse(rsp *io.ReadCloser, rspJsonSchema interface{}) (e error) {
-	var data []byte
-	if data, e = ioutil.ReadAll(*rsp); e != nil {
+func (m *nexusApi) postNexusRequest(url, contentType string, body io.Reader, rspJsonSchema interface{}) (e error) {
+	var req *http.Request
+	if req, e = http.NewRequestWithContext(m.ctx, "POST", url, body); e != nil {
 		return
 	}
 
-	return json.Unmarshal(data, &rspJsonSchema)
+	m.authorizeNexusRequest(req)
+	req.Header.Set("Content-Type", contentType)
+	m.log.Debug().Str("url", url).Msg("trying to make api request")
+
+	var rsp *http.Response
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		m.log.Warn().Msg("Could not get requested URL!")
+		return
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		return nxsErrRq404
+	}
+
+	return m.parseNexusResponse(&rsp.Body, rspJsonSchema)
 }
 
-func (m *nexusApi) getNexusFile(url string, file *os.File) (e error) {
+func (m *nexusApi) sendNexusRequest(method, url string, payload interface{}) (e error) {
+	var data []byte
+	if data, e = json.Marshal(payload); e != nil {
+		return
+	}
+
 	var req *http.Request
-	if req, e = http.NewRequest("GET", url, nil); e != nil {
+	if req, e = http.NewRequestWithContext(m.ctx, method, url, bytes.NewReader(data)); e != nil {
 		return
 	}
 
 	m.authorizeNexusRequest(req)
+	m.log.Debug().Str("url", url).Str("method", method).Msg("trying to make api request")
 
 	var rsp *http.Response
-	if rsp, e = m.Client.Do(req); e != nil {
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		m.log.Warn().Msg("Could not get requested URL!")
 		return
 	}
+	defer rsp.Body.Close()
 
-	if rsp.StatusCode != http.StatusOK {
-		gLog.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(rsp.Body)
+		m.log.Warn().Int("status", rsp.StatusCode).Str("body", string(body)).Msg("Abnormal API response! Check it immediately!")
 		return nxsErrRq404
 	}
 
+	return
+}
+
+func (m *nexusApi) getNexusRawRequest(url string) (data []byte, e error) {
+	var req *http.Request
+	if req, e = http.NewRequestWithContext(m.ctx, "GET", url, nil); e != nil {
+		return
+	}
+
+	m.log.Debug().Str("url", url).Msg("trying to make raw request")
+
+	var rsp *http.Response
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		m.log.Warn().Msg("Could not get requested URL!")
+		return
+	}
 	defer rsp.Body.Close()
-	if _, e = io.Copy(file, rsp.Body); e != nil {
+
+	if rsp.StatusCode != http.StatusOK {
+		m.log.Warn().Int("status", rsp.StatusCode).Str("url", url).Msg("Abnormal response! Check it immediately!")
+		return nil, nxsErrRq404
+	}
+
+	return ioutil.ReadAll(rsp.Body)
+}
+
+func (m *nexusApi) parseNexusResponse(rsp *io.ReadCloser, rspJsonSchema interface{}) (e error) {
+	var data []byte
+	if data, e = ioutil.ReadAll(*rsp); e != nil {
 		return
 	}
 
-	return
+	return json.Unmarshal(data, &rspJsonSchema)
+}
+
+// getNexusFile returns the file body, it's resumed with Range requests after read errors if the server supports it.
+// The transfer timeout is scaled by the given file size (0 is unknown).
+func (m *nexusApi) getNexusFile(url string, size int64) (io.ReadCloser, error) {
+	body, e := m.getNexusFileFrom(url, size, 0, "")
+	if e != nil {
+		return nil, e
+	}
+
+	return body, nil
+}
+
+// getNexusFileFrom continues the partial file from the offset with Range and If-Range (validator of the previous
+// download) requests. The whole file is returned if it has been changed or the server does not support byte ranges,
+// the body offset is 0 in this case.
+func (m *nexusApi) getNexusFileFrom(url string, size, offset int64, validator string) (file *resumableBody, e error) {
+	file = &resumableBody{api: m, url: url, validator: validator, offset: offset, resumedAt: offset}
+
+	var remaining int64
+	if size > offset {
+		remaining = size - offset
+	}
+	file.ctx, file.cancel = m.timeouts.getTransferContext(m.ctx, remaining)
+
+	var rsp *http.Response
+	if rsp, e = file.request(); e != nil {
+		file.cancel()
+		return nil, e
+	}
+
+	switch {
+	case rsp.StatusCode == http.StatusOK:
+		file.offset, file.resumedAt, file.validator = 0, 0, ""
+	case rsp.StatusCode == http.StatusPartialContent && offset != 0:
+		if start, err := getContentRangeStart(rsp); err != nil || start != offset {
+			rsp.Body.Close()
+			file.cancel()
+			return nil, errRsmChanged
+		}
+	default:
+		rsp.Body.Close()
+		file.cancel()
+		m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		return nil, nxsErrRq404
+	}
+
+	file.setResponse(rsp)
+	return file, nil
 }
 
-func (m *nexusApi) putNexusFile(url string, body *bytes.Buffer, contentType string) (e error) {
+// putNexusFile uploads the multipart body with the given size (0 is unknown). Failed uploads are retried
+// only after isUploaded check, because the previous attempt could be applied by the server even if
+// the response has been lost. Streamed bodies (not bytes buffers or readers) are not retried.
+func (m *nexusApi) putNexusFile(url string, body io.Reader, size int64, contentType string, isUploaded func() bool) (e error) {
 	var req *http.Request
-	if req, e = http.NewRequest("POST", url, body); e != nil {
+	if req, e = http.NewRequestWithContext(m.ctx, "POST", url, body); e != nil {
 		return
 	}
 
@@ -116,17 +237,62 @@ func (m *nexusApi) putNexusFile(url string, body *bytes.Buffer, contentType stri
 	req.Header.Set("Content-Type", contentType)
 
 	var rsp *http.Response
-	if rsp, e = m.Client.Do(req); e != nil {
+	if rsp, e = m.doNexusRequest(req, m.timeouts.getTransferTimeout(size), isUploaded); e != nil {
+		return
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusNoContent {
+		m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		return nxsErrRq404
+	}
+
+	return
+}
+
+// headNexusFile checks the file existence and returns its size (-1 is unknown)
+func (m *nexusApi) headNexusFile(url string) (found bool, size int64, e error) {
+	var req *http.Request
+	if req, e = http.NewRequestWithContext(m.ctx, "HEAD", url, nil); e != nil {
+		return
+	}
+
+	m.authorizeNexusRequest(req)
+
+	var rsp *http.Response
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		return
+	}
+	rsp.Body.Close()
+
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		return true, rsp.ContentLength, nil
+	case http.StatusNotFound:
+		return false, -1, nil
+	}
+
+	m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+	return false, -1, nxsErrRq404
+}
+
+func (m *nexusApi) deleteNexusRequest(url string) (e error) {
+	var req *http.Request
+	if req, e = http.NewRequestWithContext(m.ctx, "DELETE", url, nil); e != nil {
 		return
 	}
 
-	if gIsDebug {
-		// fmt.Println(m.dumpNexusRequest(req))
-		// fmt.Println(m.dumpNexusResponse(rsp))
+	m.authorizeNexusRequest(req)
+	m.log.Debug().Str("url", url).Msg("trying to make api request")
+
+	var rsp *http.Response
+	if rsp, e = m.doNexusRequest(req, m.timeouts.api, nil); e != nil {
+		return
 	}
+	defer rsp.Body.Close()
 
 	if rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusNoContent {
-		gLog.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		m.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
 		return nxsErrRq404
 	}
 
@@ -136,7 +302,7 @@ func (m *nexusApi) putNexusFile(url string, body *bytes.Buffer, contentType stri
 func (m *nexusApi) dumpNexusRequest(r *http.Request) string {
 	dump, e := httputil.DumpRequest(r, true)
 	if e != nil {
-		gLog.Warn().Err(e).Msg("")
+		m.log.Warn().Err(e).Msg("")
 	}
 	return string(dump)
 }
@@ -0,0 +1,173 @@
+package cloner
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testArtifactoryAql = `{"results":[
+	{"repo":"libs-release","path":"org/example/lib/1.0","name":"lib-1.0.jar","size":1024,"modified":"2021-10-20T10:10:10.000Z",
+		"actual_sha1":"0123456789abcdef0123456789abcdef01234567","actual_md5":"0123456789abcdef0123456789abcdef",
+		"sha256":"00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"},
+	{"repo":"libs-release","path":"org/example/lib/1.0","name":"lib-1.0-sources.jar","size":512,"actual_sha1":"89abcdef0123456789abcdef0123456789abcdef"},
+	{"repo":"libs-release","path":"org/example/lib/1.0","name":"lib-1.0.pom","size":128},
+	{"repo":"libs-release","path":"org/example/lib","name":"maven-metadata.xml","size":256},
+	{"repo":"libs-release","path":"com/other/tool/2.0","name":"tool-2.0.war","size":2048},
+	{"repo":"libs-release","path":".","name":"root-1.0.jar","size":64}
+]}`
+
+const testArtifactoryStorage = `{"uri":"http://localhost/artifactory/api/storage/libs-release","files":[
+	{"uri":"/org","folder":true},
+	{"uri":"/org/example/lib/1.0/lib-1.0.jar","size":1024,"lastModified":"2021-10-20T10:10:10.000Z","folder":false,
+		"sha1":"0123456789abcdef0123456789abcdef01234567","sha2":"00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"},
+	{"uri":"/org/example/lib/1.0/lib-1.0.pom","size":128,"folder":false},
+	{"uri":"/root-1.0.jar","size":64,"folder":false}
+]}`
+
+// newTestArtifactoryServer serves AQL and storage api of the libs-release repository under /artifactory.
+// AQL requests are forbidden if aqlStatus is not 200.
+func newTestArtifactoryServer(t *testing.T, aqlStatus int) *httptest.Server {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/artifactory/api/search/aql":
+			query, _ := ioutil.ReadAll(r.Body)
+			if !strings.HasPrefix(string(query), `items.find({"repo":"libs-release","type":"file"})`) {
+				http.Error(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+
+			if aqlStatus != http.StatusOK {
+				http.Error(w, `{"errors":[{"status":403,"message":"Forbidden"}]}`, aqlStatus)
+				return
+			}
+
+			w.Write([]byte(testArtifactoryAql))
+		case r.Method == "GET" && r.URL.Path == "/artifactory/api/storage/libs-release" && r.URL.RawQuery == "list&deep=1&listFolders=0":
+			w.Write([]byte(testArtifactoryStorage))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestArtifactory(t *testing.T, arg string) *artifactory {
+	repo, e := newArtifactory(newTestSession(&Options{})).initiate(arg)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	return repo
+}
+
+func TestArtifactoryInitiate(t *testing.T) {
+	var repo = newTestArtifactory(t, "artifactory+https://u:p@example.com/artifactory/libs-release/")
+	if repo.repository != "libs-release" || repo.endpoint.String() != "https://u:p@example.com/artifactory/" {
+		t.Errorf("got repository %q and endpoint %q", repo.repository, repo.endpoint.String())
+	}
+
+	for _, arg := range []string{"artifactory+ftp://example.com/libs-release", "artifactory+https://example.com/"} {
+		if _, e := newArtifactory(newTestSession(&Options{})).initiate(arg); e != errArtInvGivArg {
+			t.Errorf("%s: got %v, want %v", arg, e, errArtInvGivArg)
+		}
+	}
+}
+
+func TestArtifactoryAqlAssets(t *testing.T) {
+	var server = newTestArtifactoryServer(t, http.StatusOK)
+	var repo = newTestArtifactory(t, "artifactory+"+server.URL+"/artifactory/libs-release")
+
+	items, e := repo.getAqlAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(items) != 6 || items[5].Path != "root-1.0.jar" || items[5].ID != "root-1.0.jar" {
+		t.Fatalf("the file with the root path (.) must be mapped to its name, got %d items", len(items))
+	}
+
+	assets, e := repo.ListAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var paths []string
+	for _, asset := range assets {
+		paths = append(paths, asset.Path)
+	}
+
+	if want := []string{"org/example/lib/1.0/lib-1.0.jar", "org/example/lib/1.0/lib-1.0-sources.jar", "com/other/tool/2.0/tool-2.0.war"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("assets: got %q, want %q", paths, want)
+	}
+
+	var want = &NexusAsset{
+		DownloadURL:  server.URL + "/artifactory/libs-release/org/example/lib/1.0/lib-1.0.jar",
+		Path:         "org/example/lib/1.0/lib-1.0.jar",
+		ID:           "org/example/lib/1.0/lib-1.0.jar",
+		Repository:   "libs-release",
+		Format:       "maven2",
+		FileSize:     1024,
+		LastModified: "2021-10-20T10:10:10.000Z",
+		Checksum: &NexusAssetChecksum{
+			Sha1:   "0123456789abcdef0123456789abcdef01234567",
+			Sha256: "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff",
+			Md5:    "0123456789abcdef0123456789abcdef",
+		},
+		Maven2: &NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar"},
+	}
+
+	if !reflect.DeepEqual(assets[0], want) {
+		t.Errorf("got %+v, want %+v", assets[0], want)
+	}
+
+	if m := assets[1].Maven2; m == nil || m.Classifier != "sources" {
+		t.Errorf("classifier: got %+v", m)
+	}
+
+	if m := assets[2].Maven2; m == nil || m.GroupID != "com.other" || m.ArtifactID != "tool" || m.Version != "2.0" || m.Extension != "war" {
+		t.Errorf("coordinates: got %+v", m)
+	}
+}
+
+func TestArtifactoryStorageFallback(t *testing.T) {
+	var server = newTestArtifactoryServer(t, http.StatusForbidden)
+	var repo = newTestArtifactory(t, "artifactory+"+server.URL+"/artifactory/libs-release")
+
+	if _, e := repo.getAqlAssets(); e == nil {
+		t.Fatal("forbidden AQL request must be failed")
+	}
+
+	assets, e := repo.ListAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(assets) != 1 {
+		t.Fatalf("assets: got %d, want 1", len(assets))
+	}
+
+	var want = &NexusAsset{
+		DownloadURL:  server.URL + "/artifactory/libs-release/org/example/lib/1.0/lib-1.0.jar",
+		Path:         "org/example/lib/1.0/lib-1.0.jar",
+		ID:           "org/example/lib/1.0/lib-1.0.jar",
+		Repository:   "libs-release",
+		Format:       "maven2",
+		FileSize:     1024,
+		LastModified: "2021-10-20T10:10:10.000Z",
+		Checksum: &NexusAssetChecksum{
+			Sha1:   "0123456789abcdef0123456789abcdef01234567",
+			Sha256: "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff",
+		},
+		Maven2: &NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar"},
+	}
+
+	if !reflect.DeepEqual(assets[0], want) {
+		t.Errorf("got %+v, want %+v", assets[0], want)
+	}
+}
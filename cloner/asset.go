@@ -1,10 +1,26 @@
 package cloner
 
 import (
-	"errors"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
 	"os"
-	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	assetMetaFileRegexp   = regexp.MustCompile(`((maven-metadata\.xml)|\.(pom|md5|sha1|sha256|sha512))$`)
+	assetSnapshotTsRegexp = regexp.MustCompile(`^[0-9]{8}\.[0-9]{6}-[0-9]+`)
+
+	// maven local repository (~/.m2/repository) service files: failed resolution markers, unfinished
+	// downloads, resolver locks and remote repository tracking files
+	assetLocalFileRegexp = regexp.MustCompile(`(\.(lastUpdated|part|lock)|(^|/)(_remote\.repositories|_maven\.repositories|resolver-status\.properties))$`)
 )
 
 type (
@@ -20,6 +36,7 @@ type (
 		Repository   string              `json:"repository,omitempty"`
 		Format       string              `json:"format,omitempty"`
 		Checksum     *NexusAssetChecksum `json:"checksum,omitempty"`
+		FileSize     int64               `json:"fileSize,omitempty"`
 		ContentType  string              `json:"contentType,omitempty"`
 		LastModified string              `json:"lastModified,omitempty"`
 		BlobCreated  string              `json:"blobCreated,omitempty"`
@@ -39,43 +56,137 @@ type (
 		GroupID    string `json:"groupId,omitempty"`
 		ArtifactID string `json:"artifactId,omitempty"`
 		Version    string `json:"version,omitempty"`
+		Classifier string `json:"classifier,omitempty"`
 	}
 )
 
+// getTemporaryFile creates the staging file with the repository layout (tmpdir/group/artifact/version/file),
+// so assets with the same filenames do not overwrite each other. The existing file is not truncated,
+// it's continued or truncated by openStagedAsset.
+//
 // TODO
 // OPTIMIZE - https://pkg.go.dev/os@go1.17.2#OpenFile
 // !! Note - returned FD must be closed!!
 func (m *NexusAsset) getTemporaryFile(tmpdir string) (file *os.File, e error) {
-	var filename = path.Base(m.Path)
-
-	if file, e = os.OpenFile(tmpdir+"/"+filename, os.O_RDWR|os.O_CREATE, 0600); e != nil {
-		if !errors.Is(e, os.ErrNotExist) {
-			return
-		}
+	var fpath string
+	if fpath, e = joinRelativePath(tmpdir, m.Path); e != nil {
+		return
+	}
 
-		gLog.Warn().Err(e).Str("filename", filename).
-			Msg("Given filename was found. It will be rewritten in the next iterations.")
+	if e = os.MkdirAll(filepath.Dir(fpath), 0700); e != nil {
 		return
 	}
 
-	return os.Create(tmpdir + "/" + filename)
+	return os.OpenFile(fpath, os.O_RDWR|os.O_CREATE, 0600)
 }
 
 func (m *NexusAsset) isFileExists(tmpdir string) (file *os.File, e error) {
-	var filename = path.Base(m.Path)
-	if file, e = os.OpenFile(tmpdir+"/"+filename, os.O_RDONLY, 0600); e != nil {
-		if !errors.Is(e, os.ErrNotExist) {
-			return
-		}
-
-		gLog.Error().Err(e).Str("filename", filename).
-			Msg("Internal error! The asset file not found. Is download ok? The asset will be skipped.")
+	var fpath string
+	if fpath, e = joinRelativePath(tmpdir, m.Path); e != nil {
 		return
 	}
 
-	return
+	return os.OpenFile(fpath, os.O_RDONLY, 0600)
 }
 
 func (m *NexusAsset) getHumanReadbleName() string {
 	return strings.ReplaceAll(m.Path, "/", "_")
 }
+
+// getChecksumHash returns the strongest hash which checksum is known for the asset
+func (m *NexusAsset) getChecksumHash() (hash.Hash, string) {
+	switch {
+	case m.Checksum == nil:
+		return nil, ""
+	case len(m.Checksum.Sha512) != 0:
+		return sha512.New(), m.Checksum.Sha512
+	case len(m.Checksum.Sha256) != 0:
+		return sha256.New(), m.Checksum.Sha256
+	case len(m.Checksum.Sha1) != 0:
+		return sha1.New(), m.Checksum.Sha1
+	case len(m.Checksum.Md5) != 0:
+		return md5.New(), m.Checksum.Md5
+	}
+
+	return nil, ""
+}
+
+func (m *NexusAsset) isMetaFile() bool {
+	return assetMetaFileRegexp.MatchString(m.Path)
+}
+
+// filterMaven2Asset reports that the asset is matched by the path filter and it's not a meta or service file.
+// Maven2 coordinates are derived from the path, assets without them are skipped too.
+// It's used by the sources which have no search api (filesystem, maven mirror, artifactory, nexus2).
+func filterMaven2Asset(asset *NexusAsset, r *regexp.Regexp, log *zerolog.Logger) bool {
+	if !r.MatchString(asset.Path) {
+		log.Debug().Str("path", asset.Path).Msg("Asset path NOT matched!")
+		return false
+	}
+
+	if asset.isMetaFile() || assetLocalFileRegexp.MatchString(asset.Path) {
+		log.Debug().Msgf("The asset %s will be skipped!", asset.Path)
+		return false
+	}
+
+	if asset.Maven2 = getMaven2FromPath(asset.Path); asset.Maven2 == nil {
+		log.Debug().Str("path", asset.Path).Msg("Could not get maven2 coordinates from the path. The file will be skipped!")
+		return false
+	}
+
+	log.Debug().Str("path", asset.Path).Msg("Asset path matched!")
+	return true
+}
+
+// getMaven2FromPath derives maven2 coordinates from the repository layout path
+// (group/as/dirs/artifactId/version/artifactId-version[-classifier].extension).
+// It returns nil if the given path is not an artifact of maven2 layout.
+func getMaven2FromPath(p string) *NexuAssetMaven2 {
+	buf := strings.Split(strings.Trim(p, "/"), "/")
+	if len(buf) < 4 {
+		return nil
+	}
+
+	filename, version, artifactID := buf[len(buf)-1], buf[len(buf)-2], buf[len(buf)-3]
+	if !strings.HasPrefix(filename, artifactID+"-") {
+		return nil
+	}
+
+	var rest = strings.TrimPrefix(filename, artifactID+"-")
+	var baseVersion = strings.TrimSuffix(version, "SNAPSHOT")
+
+	switch {
+	case strings.HasPrefix(rest, version):
+		rest = strings.TrimPrefix(rest, version)
+	case baseVersion != version && strings.HasPrefix(rest, baseVersion):
+		// timestamped snapshot - artifactId-1.0-20211020.101010-1.jar
+		if rest = strings.TrimPrefix(rest, baseVersion); !assetSnapshotTsRegexp.MatchString(rest) {
+			return nil
+		}
+		rest = assetSnapshotTsRegexp.ReplaceAllString(rest, "")
+	default:
+		return nil
+	}
+
+	var classifier string
+	if strings.HasPrefix(rest, "-") {
+		idx := strings.Index(rest, ".")
+		if idx < 2 {
+			return nil
+		}
+
+		classifier, rest = rest[1:idx], rest[idx:]
+	}
+
+	if len(rest) < 2 || rest[0] != '.' {
+		return nil
+	}
+
+	return &NexuAssetMaven2{
+		GroupID:    strings.Join(buf[:len(buf)-3], "."),
+		ArtifactID: artifactID,
+		Version:    version,
+		Extension:  rest[1:],
+		Classifier: classifier,
+	}
+}
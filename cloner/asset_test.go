@@ -0,0 +1,77 @@
+package cloner
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGetMaven2FromPath(t *testing.T) {
+	var tests = []struct {
+		path   string
+		maven2 *NexuAssetMaven2
+	}{
+		{"org/example/lib/1.0/lib-1.0.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar"}},
+		{"/org/example/lib/1.0/lib-1.0.jar/",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar"}},
+		{"org/lib/1.0/lib-1.0.tar.gz",
+			&NexuAssetMaven2{GroupID: "org", ArtifactID: "lib", Version: "1.0", Extension: "tar.gz"}},
+		{"org/example/lib/1.0/lib-1.0-sources.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar", Classifier: "sources"}},
+		{"org/example/lib/1.0/lib-1.0-linux-x86_64.tar.gz",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "tar.gz", Classifier: "linux-x86_64"}},
+		{"org/example/lib/1.0/lib-1.0-x.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar", Classifier: "x"}},
+		{"org/example/my-lib/1.0-rc1/my-lib-1.0-rc1.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "my-lib", Version: "1.0-rc1", Extension: "jar"}},
+		{"org/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0-SNAPSHOT", Extension: "jar"}},
+		{"org/example/lib/1.0-SNAPSHOT/lib-1.0-20211020.101010-1.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0-SNAPSHOT", Extension: "jar"}},
+		{"org/example/lib/1.0-SNAPSHOT/lib-1.0-20211020.101010-12-javadoc.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0-SNAPSHOT", Extension: "jar", Classifier: "javadoc"}},
+		{"org/example/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT-tests.jar",
+			&NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0-SNAPSHOT", Extension: "jar", Classifier: "tests"}},
+
+		{"lib/1.0/lib-1.0.jar", nil},
+		{"org/example/lib/1.0/other-1.0.jar", nil},
+		{"org/example/lib/1.0/lib-2.0.jar", nil},
+		{"org/example/lib/1.0/lib-1.0", nil},
+		{"org/example/lib/1.0/lib-1.0.", nil},
+		{"org/example/lib/1.0/lib-1.0-.jar", nil},
+		{"org/example/lib/1.0-SNAPSHOT/lib-1.0-latest.jar", nil},
+	}
+
+	for _, tt := range tests {
+		if maven2 := getMaven2FromPath(tt.path); !reflect.DeepEqual(maven2, tt.maven2) {
+			t.Errorf("%q: got %+v, want %+v", tt.path, maven2, tt.maven2)
+		}
+	}
+}
+
+func TestFilterMaven2Asset(t *testing.T) {
+	var log = zerolog.Nop()
+	var r = regexp.MustCompile(`^org/`)
+
+	var tests = []struct {
+		path    string
+		matched bool
+	}{
+		{"org/example/lib/1.0/lib-1.0.jar", true},
+		{"com/example/lib/1.0/lib-1.0.jar", false},
+		{"org/example/lib/1.0/lib-1.0.pom", false},
+		{"org/example/lib/1.0/lib-1.0.jar.sha1", false},
+		{"org/example/lib/maven-metadata.xml", false},
+		{"org/example/lib/1.0/readme.txt", false},
+	}
+
+	for _, tt := range tests {
+		var asset = &NexusAsset{Path: tt.path}
+		if matched := filterMaven2Asset(asset, r, &log); matched != tt.matched || (asset.Maven2 != nil) != tt.matched {
+			t.Errorf("%q: got %v (maven2 %+v), want %v", tt.path, matched, asset.Maven2, tt.matched)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package cloner
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errCchNoKey = errors.New("The asset has no checksum which is required for the cache.")
+	errCchMiss  = errors.New("The asset has not been found in the cache.")
+
+	cacheKeyRegexp   = regexp.MustCompile(`^[0-9a-f]{32,128}$`)
+	cacheEntryRegexp = regexp.MustCompile(`^(sha1|sha256)/[0-9a-f]{2}/[0-9a-f]{32,128}$`)
+)
+
+// cacheTempMaxAge is the age of unfinished cache files which are removed on the cache opening.
+// Younger files could be written by another process which uses the same cache directory.
+const cacheTempMaxAge = 24 * time.Hour
+
+type (
+	// assetCache is the persistent content-addressed storage of downloaded assets. Files are keyed
+	// by sha256 or sha1 checksum, so the same file is downloaded once for all repositories and runs.
+	// Least recently used files are evicted if the cache size exceeds the limit.
+	assetCache struct {
+		sync.Mutex
+
+		root          string
+		size, maxSize int64
+		entries       map[string]*cacheEntry
+
+		log *zerolog.Logger
+	}
+
+	cacheEntry struct {
+		size int64
+		used time.Time
+	}
+
+	// cacheWriter is the new cache file, it's added to the cache on the successful download only
+	cacheWriter struct {
+		*os.File
+		cache *assetCache
+		key   string
+	}
+)
+
+// newAssetCache opens the cache from --cache-dir or returns nil if the cache is disabled
+func newAssetCache(s *session) (*assetCache, error) {
+	if len(s.opts.CacheDir) == 0 {
+		return nil, nil
+	}
+
+	var cache = &assetCache{
+		root:    filepath.Clean(s.opts.CacheDir),
+		maxSize: s.opts.CacheMaxSize,
+		entries: make(map[string]*cacheEntry),
+		log:     s.log,
+	}
+
+	if e := os.MkdirAll(filepath.Join(cache.root, "tmp"), 0755); e != nil {
+		return nil, e
+	}
+
+	if e := cache.removeTemporaryFiles(); e != nil {
+		return nil, e
+	}
+
+	// only files with the cache key layout are indexed, so other files of the directory are never evicted
+	for _, algo := range []string{"sha256", "sha1"} {
+		var e = filepath.Walk(filepath.Join(cache.root, algo), func(fpath string, info os.FileInfo, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+
+			var key string
+			if key, err = filepath.Rel(cache.root, fpath); err != nil {
+				return err
+			}
+
+			if key = filepath.ToSlash(key); !info.Mode().IsRegular() || !cacheEntryRegexp.MatchString(key) {
+				return nil
+			}
+
+			cache.entries[key] = &cacheEntry{size: info.Size(), used: info.ModTime()}
+			cache.size += info.Size()
+			return nil
+		})
+
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	cache.log.Info().Str("path", cache.root).Int("files", len(cache.entries)).Int64("size", cache.size).Msg("Asset cache has been opened")
+	cache.Lock()
+	cache.evict()
+	cache.Unlock()
+
+	return cache, nil
+}
+
+// removeTemporaryFiles removes unfinished files of the previous runs
+func (m *assetCache) removeTemporaryFiles() error {
+	files, e := ioutil.ReadDir(filepath.Join(m.root, "tmp"))
+	if e != nil {
+		return e
+	}
+
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !strings.HasPrefix(file.Name(), "asset") || time.Since(file.ModTime()) < cacheTempMaxAge {
+			continue
+		}
+
+		if e = os.Remove(filepath.Join(m.root, "tmp", file.Name())); e != nil && !errors.Is(e, os.ErrNotExist) {
+			m.log.Warn().Err(e).Str("filename", file.Name()).Msg("Could not remove the unfinished cache file")
+		}
+	}
+
+	return nil
+}
+
+// getCacheKey returns the cache file path (algorithm/prefix/checksum) for the asset
+func getCacheKey(asset *NexusAsset) string {
+	if asset.Checksum == nil {
+		return ""
+	}
+
+	for _, buf := range [][2]string{{"sha256", asset.Checksum.Sha256}, {"sha1", asset.Checksum.Sha1}} {
+		// checksums are given by the remote server, they must not be used as paths without validation
+		if checksum := strings.ToLower(buf[1]); cacheKeyRegexp.MatchString(checksum) {
+			return buf[0] + "/" + checksum[:2] + "/" + checksum
+		}
+	}
+
+	return ""
+}
+
+// open returns the cached asset file if it's found
+func (m *assetCache) open(asset *NexusAsset) (io.ReadCloser, bool) {
+	var key = getCacheKey(asset)
+
+	m.Lock()
+	defer m.Unlock()
+
+	entry, ok := m.entries[key]
+	if len(key) == 0 || !ok {
+		return nil, false
+	}
+
+	file, e := os.Open(filepath.Join(m.root, filepath.FromSlash(key)))
+	if e != nil {
+		m.log.Warn().Err(e).Str("key", key).Msg("Could not open the cached file. It will be removed from the cache.")
+		m.removeEntry(key)
+		return nil, false
+	}
+
+	entry.used = time.Now()
+	os.Chtimes(file.Name(), entry.used, entry.used)
+
+	return file, true
+}
+
+// create returns the writer for the new cache file
+func (m *assetCache) create(asset *NexusAsset) (_ *cacheWriter, e error) {
+	var key = getCacheKey(asset)
+	if len(key) == 0 {
+		return nil, errCchNoKey
+	}
+
+	var file *os.File
+	if file, e = ioutil.TempFile(filepath.Join(m.root, "tmp"), "asset"); e != nil {
+		return
+	}
+
+	return &cacheWriter{File: file, cache: m, key: key}, nil
+}
+
+// remove deletes the asset file (corrupted) from the cache
+func (m *assetCache) remove(asset *NexusAsset) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.removeEntry(getCacheKey(asset))
+}
+
+func (m *assetCache) removeEntry(key string) {
+	if entry, ok := m.entries[key]; ok {
+		m.size -= entry.size
+		delete(m.entries, key)
+	}
+
+	if e := os.Remove(filepath.Join(m.root, filepath.FromSlash(key))); e != nil && !errors.Is(e, os.ErrNotExist) {
+		m.log.Warn().Err(e).Str("key", key).Msg("Could not remove the cached file")
+	}
+}
+
+// evict removes least recently used files until the cache size fits the limit
+func (m *assetCache) evict() {
+	if m.maxSize <= 0 || m.size <= m.maxSize {
+		return
+	}
+
+	var keys = make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return m.entries[keys[i]].used.Before(m.entries[keys[j]].used)
+	})
+
+	for _, key := range keys {
+		if m.size <= m.maxSize {
+			break
+		}
+
+		m.log.Debug().Str("key", key).Msg("cached file has been evicted")
+		m.removeEntry(key)
+	}
+}
+
+// close adds the file to the cache if the download has been finished without errors
+func (m *cacheWriter) close(err error) {
+	var info, e = m.Stat()
+	if e == nil {
+		e = m.File.Close()
+	} else {
+		m.File.Close()
+	}
+
+	if err != nil || e != nil {
+		os.Remove(m.Name())
+		return
+	}
+
+	var fpath = filepath.Join(m.cache.root, filepath.FromSlash(m.key))
+	if e = os.MkdirAll(filepath.Dir(fpath), 0755); e == nil {
+		e = os.Rename(m.Name(), fpath)
+	}
+
+	if e != nil {
+		m.cache.log.Warn().Err(e).Str("key", m.key).Msg("Could not add the file to the cache")
+		os.Remove(m.Name())
+		return
+	}
+
+	m.cache.Lock()
+	defer m.cache.Unlock()
+
+	if entry, ok := m.cache.entries[m.key]; ok {
+		m.cache.size -= entry.size
+	}
+
+	m.cache.entries[m.key] = &cacheEntry{size: info.Size(), used: time.Now()}
+	m.cache.size += info.Size()
+	m.cache.evict()
+}
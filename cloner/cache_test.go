@@ -0,0 +1,96 @@
+package cloner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSession(opts *Options) *session {
+	var buf = getOptions(opts)
+	return &session{ctx: context.Background(), opts: buf, log: buf.Logger}
+}
+
+func writeTestFile(t *testing.T, fpath, data string, mtime time.Time) {
+	t.Helper()
+
+	if e := os.MkdirAll(filepath.Dir(fpath), 0755); e != nil {
+		t.Fatal(e)
+	}
+
+	if e := ioutil.WriteFile(fpath, []byte(data), 0644); e != nil {
+		t.Fatal(e)
+	}
+
+	if e := os.Chtimes(fpath, mtime, mtime); e != nil {
+		t.Fatal(e)
+	}
+}
+
+func TestNewAssetCacheKeepsForeignFiles(t *testing.T) {
+	var root, now = t.TempDir(), time.Now()
+	var sum = "0123456789abcdef0123456789abcdef01234567"
+
+	var cached = filepath.Join(root, "sha1", sum[:2], sum)
+	var foreign = []string{
+		filepath.Join(root, "precious.txt"),
+		filepath.Join(root, "sha1", "notes.txt"),
+		filepath.Join(root, "sha1", "zz", sum),
+		filepath.Join(root, "other", "01", sum),
+	}
+	var fresh, stale = filepath.Join(root, "tmp", "asset123"), filepath.Join(root, "tmp", "asset456")
+
+	writeTestFile(t, cached, "cached file data", now.Add(-time.Hour))
+	for _, fpath := range foreign {
+		writeTestFile(t, fpath, "foreign file data", now.Add(-2*time.Hour))
+	}
+	writeTestFile(t, fresh, "in-progress", now)
+	writeTestFile(t, stale, "unfinished", now.Add(-2*cacheTempMaxAge))
+
+	cache, e := newAssetCache(newTestSession(&Options{CacheDir: root, CacheMaxSize: 10}))
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(cache.entries) != 0 || cache.size != 0 {
+		t.Errorf("cache must be empty after the eviction, got %d files of %d bytes", len(cache.entries), cache.size)
+	}
+
+	if _, e = os.Stat(cached); !os.IsNotExist(e) {
+		t.Errorf("cached file must be evicted, got %v", e)
+	}
+
+	for _, fpath := range append(foreign, fresh) {
+		if _, e = os.Stat(fpath); e != nil {
+			t.Errorf("file %s must be kept: %v", fpath, e)
+		}
+	}
+
+	if _, e = os.Stat(stale); !os.IsNotExist(e) {
+		t.Errorf("stale temporary file must be removed, got %v", e)
+	}
+}
+
+func TestGetCacheKey(t *testing.T) {
+	var sha1, sha256 = "0123456789ABCDEF0123456789abcdef01234567", "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff"
+
+	var tests = []struct {
+		name     string
+		checksum *NexusAssetChecksum
+		key      string
+	}{
+		{"sha256 is preferred", &NexusAssetChecksum{Sha1: sha1, Sha256: sha256}, "sha256/00/" + sha256},
+		{"sha1 is lowercased", &NexusAssetChecksum{Sha1: sha1}, "sha1/01/0123456789abcdef0123456789abcdef01234567"},
+		{"invalid sha256", &NexusAssetChecksum{Sha1: sha1, Sha256: "../../etc/passwd"}, "sha1/01/0123456789abcdef0123456789abcdef01234567"},
+		{"no checksums", &NexusAssetChecksum{}, ""},
+	}
+
+	for _, tt := range tests {
+		if key := getCacheKey(&NexusAsset{Checksum: tt.checksum}); key != tt.key {
+			t.Errorf("%s: got %q, want %q", tt.name, key, tt.key)
+		}
+	}
+}
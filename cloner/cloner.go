@@ -1,59 +1,144 @@
 package cloner
 
 import (
+	"context"
 	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
 	"regexp"
-	"strings"
-
-	"github.com/rs/zerolog"
-	"gopkg.in/urfave/cli.v1"
 )
 
 type Cloner struct {
-	srcNexus, dstNexus *nexus
-}
+	src Source
+	dst Destination
 
-var (
-	gLog     *zerolog.Logger
-	gCli     *cli.Context
-	gApi     *nexusApi
-	gIsDebug bool
-)
+	stop context.Context // stops new transfers
+
+	report *RepositoryReport
+	cache  *assetCache
+	*session
+	tempStorage
+}
 
 var (
 	errClNoMissAssets = errors.New("There is no missing assets detected. Repository sinchronization is not needed.")
+	errRepoReadOnly   = errors.New("Given repository endpoint could be used as a source only.")
+	errClChecksum     = errors.New("Downloaded file is corrupted. Its checksum does not match the source asset checksum.")
+	errNxsDwnlErrs    = errors.New("Download process has not successfully finished. Check logs and restart program. Also u can use --skip-download-errors flag.")
 )
 
-func NewCloner(l *zerolog.Logger) *Cloner {
-	gLog = l
-	return &Cloner{}
+// New returns the cloner with the given options (nil is the default options). Options are copied, so they could be
+// reused for other cloners.
+func New(opts *Options) *Cloner {
+	var buf = getOptions(opts)
+	var s = &session{ctx: context.Background(), opts: buf, log: buf.Logger}
+
+	return &Cloner{session: s, tempStorage: tempStorage{session: s}}
+}
+
+// WithEndpoints sets custom source and destination endpoints instead of parsing them from arguments
+func (m *Cloner) WithEndpoints(src Source, dst Destination) *Cloner {
+	m.src, m.dst = src, dst
+	return m
 }
 
-func (m *Cloner) Bootstrap(ctx *cli.Context) error {
-	gCli = ctx
+// Sync synchronizes the source repositories with the destination ones. New transfers are not started
+// after ctx is done, in-flight transfers are cancelled after ShutdownTimeout. The report is nil
+// if the synchronization has not been started.
+func (m *Cloner) Sync(ctx context.Context) (report *Report, e error) {
+	var cancel = m.initContext(ctx)
+	defer cancel()
 
-	if strings.ToLower(gCli.String("loglevel")) == "debug" {
-		gIsDebug = true
+	if m.cache, e = newAssetCache(m.session); e != nil {
+		return
 	}
 
-	var e error
-	if m.srcNexus, e = newNexus().initiate(gCli.Args().Get(0)); e != nil {
-		return e
+	if m.opts.All || len(m.opts.RepoRegex) != 0 {
+		return m.syncRepositories(m.opts.Source, m.opts.Destination)
 	}
 
-	if m.dstNexus, e = newNexus().initiate(gCli.Args().Get(1)); e != nil {
-		return e
+	if m.src == nil {
+		if m.src, e = newSource(m.session, m.opts.Source); e != nil {
+			return
+		}
 	}
 
-	defer func() {
-		m.srcNexus.destruct()
-		m.dstNexus.destruct()
-	}()
+	if m.dst == nil {
+		if m.dst, e = newDestination(m.session, m.opts.Destination); e != nil {
+			return
+		}
+	}
+
+	if src, ok := m.src.(*nexus); ok && len(src.members) != 0 {
+		switch m.opts.GroupMode {
+		case "flatten":
+		case "members":
+			return m.syncGroupMembers(src)
+		default:
+			return nil, errGrpInvMode
+		}
+	}
 
-	return m.sync()
+	m.report = &RepositoryReport{Source: getRedactedArgument(m.opts.Source), Destination: getRedactedArgument(m.opts.Destination)}
+
+	defer m.destruct()
+	var err = m.sync()
+
+	// the report is printed for failed synchronization too, like in the repositories modes
+	if report, e = m.finish([]*Cloner{m}); err != nil && !m.isInterrupted() {
+		return report, err
+	}
+
+	return
+}
+
+// newRepositoryCloner returns the cloner of the repositories pair, it shares the session and the cache
+func (m *Cloner) newRepositoryCloner(src Source, dst Destination, report *RepositoryReport) *Cloner {
+	return &Cloner{
+		src:         src,
+		dst:         dst,
+		stop:        m.stop,
+		report:      report,
+		cache:       m.cache,
+		session:     m.session,
+		tempStorage: tempStorage{session: m.session, repository: report.Source},
+	}
+}
+
+// getRedactedArgument returns the endpoint argument without password for reports
+func getRedactedArgument(arg string) string {
+	if rrl, e := url.Parse(arg); e == nil {
+		return rrl.Redacted()
+	}
+
+	return arg
 }
 
 func (m *Cloner) sync() (e error) {
+	if m.report == nil {
+		m.report = &RepositoryReport{}
+	}
+	defer func() {
+		// requests which have been cancelled by --shutdown-timeout are not the synchronization errors
+		if m.isInterrupted() && errors.Is(e, context.Canceled) {
+			e = errClInterrupted
+		}
+
+		m.report.Err = e
+	}()
+
+	if m.isInterrupted() {
+		return errClInterrupted
+	}
+
+	// 0. create missing destination repository
+	if m.opts.CreateMissingRepos && !m.opts.WarmProxy {
+		if e = m.createMissingRepository(); e != nil {
+			return
+		}
+	}
 
 	// 1. get data from src and dst repositories
 	var srcAssets, dstAssets []*NexusAsset
@@ -67,39 +152,104 @@ func (m *Cloner) sync() (e error) {
 	if missAssets = m.getMissingAssets(srcAssets, dstAssets); len(missAssets) == 0 {
 		return // errClNoMissAssets
 	}
+	m.report.Missing, m.report.SourceMembers = len(missAssets), make(map[string]int)
+	m.report.pending = make(map[string]bool, len(missAssets))
+	for _, asset := range missAssets {
+		m.report.SourceMembers[asset.Repository]++
+		m.report.pending[asset.Path] = true
+	}
+
+	// 3. request missed assets through the destination proxy instead of downloading and uploading
+	if m.opts.WarmProxy {
+		return m.warmMissingAssets(missAssets)
+	}
 
 	// 3. download missed assets from src repository
-	if gCli.Bool("skip-download") {
+	if m.opts.SkipDownload {
 		return
 	}
 
-	if e = m.srcNexus.createTemporaryDirectory(); e != nil {
+	// 3.1 stream missed assets from src to dst repository, failed assets are transferred with the disk staging
+	if m.opts.Stream && !m.opts.SkipUpload {
+		if missAssets, e = m.streamMissingAssets(missAssets); e != nil || len(missAssets) == 0 {
+			return
+		}
+
+		m.log.Warn().Int("count", len(missAssets)).Msg("Some assets have not been streamed. They will be transferred with the disk staging.")
+	}
+
+	if e = m.createTemporaryDirectory(); e != nil {
 		return
 	}
 
-	if e = m.srcNexus.downloadMissingAssets(missAssets); e != nil {
+	// 3.2 check free space of the temporary directory, split assets by --max-staging-bytes
+	var batches [][]*NexusAsset
+	if batches, e = m.getStagingBatches(missAssets); e != nil {
+		return
+	}
+
+	for i, batch := range batches {
+		if m.isInterrupted() {
+			return errClInterrupted
+		}
+
+		if len(batches) > 1 {
+			m.log.Info().Int("batch", i+1).Int("batches", len(batches)).Int("count", len(batch)).Msg("Starting the staging batch")
+		}
+
+		if e = m.syncStagingBatch(batch, len(batches) > 1); e != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// syncStagingBatch downloads assets to the temporary directory and uploads them.
+// Staged files are removed after the upload if there are several batches.
+func (m *Cloner) syncStagingBatch(assets []*NexusAsset, cleanup bool) (e error) {
+	var dwnAssets []*NexusAsset
+	dwnAssets, e = m.downloadMissingAssets(assets)
+	m.report.Downloaded += len(dwnAssets)
+
+	if e != nil {
 		return
 	}
 
 	// 4. Uplaod missed assets
-	if gCli.Bool("skip-upload") {
+	if m.opts.SkipUpload {
 		return
 	}
 
-	m.dstNexus.setTemporaryDirectory(m.srcNexus.getTemporaryDirectory())
-	if e = m.dstNexus.uploadMissingAssets(missAssets); e != nil {
+	if e = m.uploadMissingAssets(dwnAssets); e != nil {
 		return
 	}
 
+	if cleanup && !m.opts.TempPathSave {
+		m.removeStagedAssets(assets)
+	}
+
 	return
 }
 
+func (m *Cloner) createMissingRepository() error {
+	src, srcOk := m.src.(*nexus)
+	dst, dstOk := m.dst.(*nexus)
+
+	if !srcOk || !dstOk {
+		m.log.Warn().Msg("Repository creation is supported for Nexus source and destination only. It will be skipped.")
+		return nil
+	}
+
+	return dst.createRepositoryFrom(src)
+}
+
 func (m *Cloner) getMetaFromRepositories() (srcAssets, dstAssets []*NexusAsset, e error) {
-	if srcAssets, e = m.srcNexus.getRepositoryAssets(); e != nil {
+	if srcAssets, e = m.src.ListAssets(); e != nil {
 		return
 	}
 
-	if dstAssets, e = m.dstNexus.getRepositoryAssets(); e != nil {
+	if dstAssets, e = m.dst.ListAssets(); e != nil {
 		return
 	}
 
@@ -110,7 +260,7 @@ func (m *Cloner) getMissingAssets(srcACollection, dstACollection []*NexusAsset)
 	var dstAssets = make(map[string]*NexusAsset, len(dstACollection))
 	var skippedAssets int
 
-	gLog.Debug().Int("srcColl", len(srcACollection)).Int("dstColl", len(dstACollection)).Msg("Starting search of missing assets")
+	m.log.Debug().Int("srcColl", len(srcACollection)).Int("dstColl", len(dstACollection)).Msg("Starting search of missing assets")
 
 	for _, asset := range dstACollection {
 		dstAssets[asset.getHumanReadbleName()] = asset
@@ -118,7 +268,7 @@ func (m *Cloner) getMissingAssets(srcACollection, dstACollection []*NexusAsset)
 
 	for _, asset := range srcACollection {
 		if matched, _ := regexp.MatchString("((maven-metadata\\.xml)|\\.(pom|md5|sha1|sha256|sha512))$", asset.getHumanReadbleName()); matched {
-			gLog.Debug().Msgf("The asset %s will be skipped!", asset.getHumanReadbleName())
+			m.log.Debug().Msgf("The asset %s will be skipped!", asset.getHumanReadbleName())
 			skippedAssets = skippedAssets + 1
 			continue
 		}
@@ -128,17 +278,186 @@ func (m *Cloner) getMissingAssets(srcACollection, dstACollection []*NexusAsset)
 		}
 	}
 
-	if gIsDebug {
-		for _, asset := range missingAssets {
-			gLog.Debug().Msg("Missing asset - " + asset.getHumanReadbleName())
+	for _, asset := range missingAssets {
+		m.log.Debug().Str("repo", asset.Repository).Msg("Missing asset - " + asset.getHumanReadbleName())
+	}
+
+	m.log.Info().Msgf("There are %d missing assets in destination repository. Filelist u can see in debug logs.", len(missingAssets))
+	m.log.Info().Msgf("%d assets was skipped because of regexp match.", skippedAssets)
+	return
+}
+
+// TODO
+// show download progress
+// https://golangcode.com/download-a-file-with-progress/ - example
+func (m *Cloner) downloadMissingAssets(assets []*NexusAsset) (downloaded []*NexusAsset, e error) {
+	var dwnListCount = len(assets)
+	var errors int
+
+	for _, asset := range assets {
+		if m.isInterrupted() {
+			return downloaded, errClInterrupted
+		}
+
+		if e = m.downloadAsset(asset); e != nil {
+			m.log.Error().Err(e).Msgf("There is error while downloading asset. Asset %s will be skipped.", asset.ID)
+			if m.isInterrupted() {
+				return downloaded, errClInterrupted
+			}
+
+			m.report.Failed++
+			errors++
+			continue
+		}
+
+		if m.opts.SkipUpload {
+			m.report.complete(asset)
+		}
+
+		downloaded = append(downloaded, asset)
+		m.log.Info().Msgf("%s file has been downloaded successfully. Remaining %d files.", asset.getHumanReadbleName(), dwnListCount-len(downloaded))
+	}
+
+	if errors > 0 {
+		m.log.Warn().Msgf("There was %d troubles with file downloading. Check logs and try again later.", errors) // TODO retranslate
+		if !m.opts.SkipDownloadErrors {
+			return downloaded, errNxsDwnlErrs
+		}
+	}
+
+	m.log.Info().Msgf("Missing assets successfully . Downloaded %d files.", len(downloaded))
+	return downloaded, nil
+}
+
+func (m *Cloner) downloadAsset(asset *NexusAsset) (e error) {
+	var file *os.File
+	if file, e = asset.getTemporaryFile(m.tempPath); e != nil {
+		return
+	}
+	defer file.Close()
+
+	if m.cache != nil {
+		if e = m.copyCachedAsset(asset, file); e != errCchMiss {
+			return
+		}
+	}
+
+	var body io.ReadCloser
+	var offset int64
+	if body, offset, e = m.openStagedAsset(asset, file); e != nil {
+		return
+	}
+	defer body.Close()
+
+	var cached *cacheWriter
+	if m.cache != nil {
+		if cached, e = m.cache.create(asset); e != nil && e != errCchNoKey {
+			m.log.Warn().Err(e).Str("filename", asset.getHumanReadbleName()).Msg("Could not create the cache file")
+		}
+	}
+
+	// the checksum of the continued file is verified from the beginning, the staged part is not written again
+	var checked = newChecksumReader(io.MultiReader(io.NewSectionReader(file, 0, offset), body), asset, m.log)
+	var staged, writer io.Writer = ioutil.Discard, file
+	if cached != nil {
+		staged, writer = cached, io.MultiWriter(file, cached)
+	}
+
+	if _, e = io.CopyN(staged, checked, offset); e == nil {
+		_, e = io.Copy(writer, checked)
+	}
+
+	if cached != nil {
+		cached.close(e)
+	}
+
+	// corrupted files are not continued
+	if e == errClChecksum {
+		if err := truncateStagedFile(file); err != nil {
+			m.log.Warn().Err(err).Str("filename", asset.getHumanReadbleName()).Msg("Could not truncate the corrupted staging file")
 		}
 	}
 
-	gLog.Info().Msgf("There are %d missing assets in destination repository. Filelist u can see in debug logs.", len(missingAssets))
-	gLog.Info().Msgf("%d assets was skipped because of regexp match.", skippedAssets)
 	return
 }
 
+// copyCachedAsset copies the asset from the cache instead of the downloading. Corrupted cache files
+// are removed and errCchMiss is returned, the file is truncated for the downloading in this case.
+func (m *Cloner) copyCachedAsset(asset *NexusAsset, file *os.File) (e error) {
+	body, ok := m.cache.open(asset)
+	if !ok {
+		return errCchMiss
+	}
+	defer body.Close()
+
+	if e = truncateStagedFile(file); e != nil {
+		return
+	}
+
+	if _, e = io.Copy(file, newChecksumReader(body, asset, m.log)); e == nil {
+		m.log.Debug().Str("filename", asset.getHumanReadbleName()).Msg("asset has been found in the cache")
+		m.report.Cached++
+		return
+	}
+
+	m.log.Warn().Err(e).Str("filename", asset.getHumanReadbleName()).Msg("Cached file is corrupted. It will be removed from the cache.")
+	m.cache.remove(asset)
+
+	if e = truncateStagedFile(file); e != nil {
+		return
+	}
+
+	return errCchMiss
+}
+
+func (m *Cloner) uploadMissingAssets(assets []*NexusAsset) error {
+	var isErrored bool
+	var assetsCount = len(assets)
+
+	for _, asset := range assets {
+		if m.isInterrupted() {
+			return errClInterrupted
+		}
+
+		file, e := asset.isFileExists(m.tempPath)
+		if e != nil {
+			m.report.Failed++
+			isErrored = true
+			m.log.Error().Err(e).Str("filename", asset.getHumanReadbleName()).
+				Msg("Could not find the asset's file. Asset will be skipped!")
+			continue
+		}
+
+		m.log.Debug().Msg("asset - " + asset.getHumanReadbleName())
+
+		e = m.dst.WriteAsset(asset, file)
+		file.Close()
+
+		if e != nil {
+			m.log.Error().Err(e).Str("filename", asset.getHumanReadbleName()).
+				Msg("Could not upload the asset's file. Asset will be skipped!")
+			if m.isInterrupted() {
+				return errClInterrupted
+			}
+
+			m.report.Failed++
+			isErrored = true
+			continue
+		}
+
+		assetsCount--
+		m.report.Uploaded++
+		m.report.complete(asset)
+		m.log.Info().Msgf("The asset %s has been uploaded successfully. Remaining %d files", asset.getHumanReadbleName(), assetsCount)
+	}
+
+	if isErrored {
+		m.log.Warn().Msg("There was some errors in the upload proccess. Check logs and try again.")
+	}
+
+	return nil
+}
+
 // TODO CODE
 // queue module
 
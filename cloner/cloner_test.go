@@ -0,0 +1,48 @@
+package cloner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// testFailedSource is the source which could not list its assets
+type testFailedSource struct{}
+
+var errTestListAssets = errors.New("Could not list the test assets.")
+
+func (testFailedSource) ListAssets() ([]*NexusAsset, error)           { return nil, errTestListAssets }
+func (testFailedSource) OpenAsset(*NexusAsset) (io.ReadCloser, error) { return nil, errTestListAssets }
+
+func TestNewWithNilOptions(t *testing.T) {
+	var cloner = New(nil)
+	if cloner.opts == nil || cloner.log == nil || cloner.opts.GroupMode != "flatten" {
+		t.Errorf("got options %+v, want the default options", cloner.opts)
+	}
+}
+
+func TestSyncFailedRepositoryReport(t *testing.T) {
+	var buf bytes.Buffer
+	var log = zerolog.New(&buf)
+
+	var cloner = New(&Options{Logger: &log}).WithEndpoints(testFailedSource{}, newTestFilesystem(t, t.TempDir(), &Options{}))
+
+	report, e := cloner.Sync(context.Background())
+	if e != errTestListAssets {
+		t.Errorf("got error %v, want %v", e, errTestListAssets)
+	}
+
+	if report == nil || len(report.Repositories) != 1 || report.Repositories[0].Status != "failed" {
+		t.Fatalf("got report %+v, want the failed repository", report)
+	}
+
+	// the failed synchronization is reported like in the repositories modes
+	if !strings.Contains(buf.String(), "Repository synchronization report") {
+		t.Errorf("report has not been printed: %s", buf.String())
+	}
+}
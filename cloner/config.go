@@ -0,0 +1,360 @@
+package cloner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	errCfgUnknownKind = errors.New("There is unknown configuration item in --items option. Check usage page for supported items.")
+	errCfgCloneErrs   = errors.New("Some configuration items have not been cloned successfully. Check the report and logs for more information.")
+)
+
+type (
+	// nexusConfigItem is the configuration object of the nexus REST api (blob store, role, etc)
+	nexusConfigItem map[string]interface{}
+
+	// nexusConfigKind describes REST api paths and fields of the configuration objects
+	nexusConfigKind struct {
+		name, listPath, key string
+
+		itemPath   func(item nexusConfigItem) string // GET (if detailed) and PUT path
+		createPath func(item nexusConfigItem) string // POST path
+
+		detailed     bool     // list response has no full configuration, it's requested by itemPath
+		skipReadOnly bool     // items with readOnly flag (nexus built-in) are not cloned
+		withPassword bool     // created items require password (users)
+		readOnly     []string // fields which are ignored by diff and are not sent to the destination
+		updateOmit   []string // fields which are not sent with PUT requests
+	}
+
+	configCloneOptions struct {
+		dryRun bool
+		update bool // existing items which differ are updated, otherwise they are skipped
+
+		passwords     string // skip or generate passwords for created users
+		passwordsFile io.Writer
+	}
+
+	configReport struct {
+		created, updated, unchanged, skipped, failed int
+	}
+)
+
+var nexusConfigKinds = []*nexusConfigKind{
+	{
+		name:     "blobstores",
+		listPath: "/service/rest/v1/blobstores",
+		key:      "name",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/blobstores/" + strings.ToLower(item.getString("type")) + "/" + url.PathEscape(item.getString("name"))
+		},
+		createPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/blobstores/" + strings.ToLower(item.getString("type"))
+		},
+		detailed: true,
+		readOnly: []string{"type", "blobCount", "totalSizeInBytes", "availableSpaceInBytes", "unavailable"},
+	},
+	{
+		name:     "cleanup-policies",
+		listPath: "/service/rest/v1/cleanup-policies",
+		key:      "name",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/cleanup-policies/" + url.PathEscape(item.getString("name"))
+		},
+		createPath: func(nexusConfigItem) string { return "/service/rest/v1/cleanup-policies" },
+	},
+	{
+		name:     "routing-rules",
+		listPath: "/service/rest/v1/routing-rules",
+		key:      "name",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/routing-rules/" + url.PathEscape(item.getString("name"))
+		},
+		createPath: func(nexusConfigItem) string { return "/service/rest/v1/routing-rules" },
+	},
+	{
+		name:     "content-selectors",
+		listPath: "/service/rest/v1/security/content-selectors",
+		key:      "name",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/security/content-selectors/" + url.PathEscape(item.getString("name"))
+		},
+		createPath: func(nexusConfigItem) string { return "/service/rest/v1/security/content-selectors" },
+		readOnly:   []string{"type"},
+		updateOmit: []string{"name"},
+	},
+}
+
+// getConfigKind returns the configuration kind by its name
+func getConfigKind(name string) *nexusConfigKind {
+	for _, kind := range nexusConfigKinds {
+		if kind.name == name {
+			return kind
+		}
+	}
+
+	return nil
+}
+
+func (m nexusConfigItem) getString(key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+// getPayload returns the item copy without the given fields
+func (m nexusConfigItem) getPayload(omit ...[]string) nexusConfigItem {
+	var payload = make(nexusConfigItem, len(m))
+	for key, value := range m {
+		payload[key] = value
+	}
+
+	for _, fields := range omit {
+		for _, field := range fields {
+			delete(payload, field)
+		}
+	}
+
+	return payload
+}
+
+// getDiff returns the sorted list of top level fields which are different in the given items
+func (m nexusConfigItem) getDiff(item nexusConfigItem) (fields []string) {
+	for key, value := range m {
+		if !reflect.DeepEqual(value, item[key]) {
+			fields = append(fields, key)
+		}
+	}
+
+	for key := range item {
+		if _, ok := m[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+
+	sort.Strings(fields)
+	return
+}
+
+func (m *nexus) getConfigItems(kind *nexusConfigKind) (items []nexusConfigItem, e error) {
+	var rrl *url.URL
+	if rrl, e = m.getNexusURL(kind.listPath); e != nil {
+		return
+	}
+
+	if e = m.api.getNexusRequest(rrl.String(), &items); e != nil {
+		return nil, e
+	}
+
+	if !kind.detailed {
+		return
+	}
+
+	for idx, item := range items {
+		var detail nexusConfigItem
+		if rrl, e = m.getNexusURL(kind.itemPath(item)); e != nil {
+			return
+		}
+
+		if e = m.api.getNexusRequest(rrl.String(), &detail); e != nil {
+			return nil, e
+		}
+
+		// detail responses could have no key and type fields
+		for key, value := range item {
+			if _, ok := detail[key]; !ok && (key == kind.key || key == "type") {
+				detail[key] = value
+			}
+		}
+
+		items[idx] = detail
+	}
+
+	return
+}
+
+func (m *nexus) sendConfigItem(method, path string, payload nexusConfigItem) (e error) {
+	var rrl *url.URL
+	if rrl, e = m.getNexusURL(path); e != nil {
+		return
+	}
+
+	return m.api.sendNexusRequest(method, rrl.String(), payload)
+}
+
+// CloneConfig clones the server configuration (config-clone command). Source and Destination options
+// are Nexus server urls.
+func (m *Cloner) CloneConfig(ctx context.Context, opts *ConfigOptions) (e error) {
+	var cancel = m.initContext(ctx)
+	defer cancel()
+
+	var kinds []*nexusConfigKind
+	if kinds, e = m.getConfigKinds(nexusConfigKinds, opts.Items); e != nil {
+		return
+	}
+
+	var src, dst *nexus
+	if src, dst, e = initiateServers(m.session, m.opts.Source, m.opts.Destination); e != nil {
+		return
+	}
+
+	return m.cloneConfig(src, dst, kinds, &configCloneOptions{dryRun: opts.DryRun, update: opts.UpdateExisting})
+}
+
+// getConfigKinds returns configuration kinds from the comma separated list
+func (m *Cloner) getConfigKinds(supported []*nexusConfigKind, items string) (kinds []*nexusConfigKind, e error) {
+	for _, name := range strings.Split(items, ",") {
+		var found bool
+		for _, kind := range supported {
+			if kind.name == strings.TrimSpace(name) {
+				kinds, found = append(kinds, kind), true
+				break
+			}
+		}
+
+		if !found {
+			m.log.Error().Str("item", name).Msg("Unknown configuration item")
+			return nil, errCfgUnknownKind
+		}
+	}
+
+	return
+}
+
+func (m *Cloner) cloneConfig(src, dst *nexus, kinds []*nexusConfigKind, opts *configCloneOptions) (e error) {
+	for _, kind := range kinds {
+		if m.isInterrupted() {
+			m.log.Warn().Str("item", kind.name).Msg("Configuration clone has been interrupted. Remaining items will be skipped.")
+			return errClInterrupted
+		}
+
+		var report *configReport
+		var err error
+
+		if report, err = m.cloneConfigKind(src, dst, kind, opts); err != nil {
+			m.log.Error().Err(err).Str("item", kind.name).Msg("Could not clone configuration items")
+			e = errCfgCloneErrs
+			continue
+		}
+
+		var event = m.log.Info()
+		if report.failed != 0 {
+			event, e = m.log.Error(), errCfgCloneErrs
+		}
+
+		event.Str("item", kind.name).Bool("dry_run", opts.dryRun).Int("created", report.created).Int("updated", report.updated).
+			Int("unchanged", report.unchanged).Int("skipped", report.skipped).Int("failed", report.failed).
+			Msg("Configuration clone report")
+	}
+
+	return
+}
+
+// cloneConfigKind creates missing items on the destination and updates existing ones if they differ.
+// Existing items are updated with --update-existing option only.
+func (m *Cloner) cloneConfigKind(src, dst *nexus, kind *nexusConfigKind, opts *configCloneOptions) (report *configReport, e error) {
+	var srcItems, dstItems []nexusConfigItem
+	if srcItems, e = src.getConfigItems(kind); e != nil {
+		return
+	}
+
+	if dstItems, e = dst.getConfigItems(kind); e != nil {
+		return
+	}
+
+	var dstIndex = make(map[string]nexusConfigItem, len(dstItems))
+	for _, item := range dstItems {
+		dstIndex[item.getString(kind.key)] = item
+	}
+
+	report = &configReport{}
+	for _, item := range srcItems {
+		var name = item.getString(kind.key)
+		var payload = item.getPayload(kind.readOnly)
+
+		if readOnly, _ := item["readOnly"].(bool); kind.skipReadOnly && readOnly {
+			m.log.Debug().Str("item", kind.name).Str("name", name).Msg("Configuration item is read only and will be skipped")
+			report.skipped++
+			continue
+		}
+
+		dstItem, found := dstIndex[name]
+		if !found {
+			if kind.withPassword && opts.passwords != "generate" {
+				m.log.Warn().Str("item", kind.name).Str("name", name).
+					Msg("Configuration item is missing, but it requires password. Use --user-passwords generate for its creation.")
+				report.skipped++
+				continue
+			}
+
+			m.log.Info().Str("item", kind.name).Str("name", name).Bool("dry_run", opts.dryRun).Msg("Configuration item is missing and will be created")
+
+			if !opts.dryRun {
+				if err := m.createConfigItem(dst, kind, item, payload, opts); err != nil {
+					m.log.Error().Err(err).Str("item", kind.name).Str("name", name).Msg("Could not create configuration item")
+					report.failed++
+					continue
+				}
+			}
+
+			report.created++
+			continue
+		}
+
+		var diff = payload.getDiff(dstItem.getPayload(kind.readOnly))
+		if len(diff) == 0 {
+			m.log.Debug().Str("item", kind.name).Str("name", name).Msg("Configuration item is up to date")
+			report.unchanged++
+			continue
+		}
+
+		if !opts.update {
+			m.log.Warn().Str("item", kind.name).Str("name", name).Strs("diff", diff).
+				Msg("Configuration item differs, but it will be skipped. Use --update-existing for its update.")
+			report.skipped++
+			continue
+		}
+
+		m.log.Info().Str("item", kind.name).Str("name", name).Strs("diff", diff).Bool("dry_run", opts.dryRun).
+			Msg("Configuration item differs and will be updated")
+
+		if !opts.dryRun {
+			if err := dst.sendConfigItem("PUT", kind.itemPath(item), payload.getPayload(kind.updateOmit)); err != nil {
+				m.log.Error().Err(err).Str("item", kind.name).Str("name", name).Msg("Could not update configuration item")
+				report.failed++
+				continue
+			}
+		}
+
+		report.updated++
+	}
+
+	return
+}
+
+func (m *Cloner) createConfigItem(dst *nexus, kind *nexusConfigKind, item, payload nexusConfigItem, opts *configCloneOptions) (e error) {
+	if !kind.withPassword {
+		return dst.sendConfigItem("POST", kind.createPath(item), payload)
+	}
+
+	var password string
+	if password, e = getRandomPassword(); e != nil {
+		return
+	}
+
+	payload = payload.getPayload()
+	payload["password"] = password
+
+	if e = dst.sendConfigItem("POST", kind.createPath(item), payload); e != nil {
+		return
+	}
+
+	_, e = fmt.Fprintf(opts.passwordsFile, "%s:%s\n", item.getString(kind.key), password)
+	return
+}
@@ -0,0 +1,199 @@
+package cloner
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testConfigServer is the fake nexus with configuration api. Items are listed by the api path,
+// POST and PUT requests are kept with their payloads.
+type testConfigServer struct {
+	*httptest.Server
+	sync.Mutex
+
+	items    map[string][]nexusConfigItem // list path -> items
+	writes   []string                     // "METHOD path"
+	payloads []nexusConfigItem
+}
+
+func newTestConfigServer(t *testing.T, items map[string][]nexusConfigItem) *testConfigServer {
+	var server = &testConfigServer{items: items}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Lock()
+		defer server.Unlock()
+
+		switch r.Method {
+		case "GET":
+			items, ok := server.items[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			json.NewEncoder(w).Encode(items)
+		case "POST", "PUT":
+			var payload nexusConfigItem
+			if e := json.NewDecoder(r.Body).Decode(&payload); e != nil {
+				http.Error(w, e.Error(), http.StatusBadRequest)
+				return
+			}
+
+			server.writes, server.payloads = append(server.writes, r.Method+" "+r.URL.Path), append(server.payloads, payload)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCloneConfig(t *testing.T) {
+	var tests = []struct {
+		name     string
+		security bool
+		cfg      ConfigOptions
+		writes   []string
+		users    []string // users with generated passwords
+	}{
+		{"default", true, ConfigOptions{Items: "roles,users", UserPasswords: "skip"},
+			[]string{"POST /service/rest/v1/security/roles"}, nil},
+		{"update existing", true, ConfigOptions{Items: "roles,users", UserPasswords: "skip", UpdateExisting: true},
+			[]string{"POST /service/rest/v1/security/roles", "PUT /service/rest/v1/security/roles/ops", "PUT /service/rest/v1/security/users/admin"}, nil},
+		{"generated passwords", true, ConfigOptions{Items: "roles,users", UserPasswords: "generate"},
+			[]string{"POST /service/rest/v1/security/roles", "POST /service/rest/v1/security/users"}, []string{"jdoe"}},
+		{"dry run", true, ConfigOptions{Items: "roles,users", UserPasswords: "generate", UpdateExisting: true, DryRun: true},
+			nil, nil},
+		{"configuration default", false, ConfigOptions{Items: "content-selectors"},
+			[]string{"POST /service/rest/v1/security/content-selectors"}, nil},
+		{"configuration update", false, ConfigOptions{Items: "content-selectors", UpdateExisting: true},
+			[]string{"PUT /service/rest/v1/security/content-selectors/docs", "POST /service/rest/v1/security/content-selectors"}, nil},
+	}
+
+	for _, tt := range tests {
+		var srcServer = newTestConfigServer(t, map[string][]nexusConfigItem{
+			"/service/rest/v1/security/roles": {
+				{"id": "nx-admin", "source": "default", "readOnly": true},
+				{"id": "dev", "source": "default", "privileges": []interface{}{"read"}},
+				{"id": "ops", "source": "default", "privileges": []interface{}{"read", "write"}},
+				{"id": "qa", "source": "default", "privileges": []interface{}{"read"}},
+			},
+			"/service/rest/v1/security/users": {
+				{"userId": "anonymous", "status": "active", "readOnly": true},
+				{"userId": "admin", "status": "active", "roles": []interface{}{"nx-admin"}},
+				{"userId": "jdoe", "status": "active", "roles": []interface{}{"dev"}},
+			},
+			"/service/rest/v1/security/content-selectors": {
+				{"name": "docs", "type": "csel", "expression": "path =^ \"/docs/\""},
+				{"name": "libs", "type": "csel", "expression": "path =^ \"/libs/\""},
+			},
+		})
+
+		var dstServer = newTestConfigServer(t, map[string][]nexusConfigItem{
+			"/service/rest/v1/security/roles": {
+				{"id": "ops", "source": "default", "privileges": []interface{}{"read"}},
+				{"id": "qa", "source": "default", "privileges": []interface{}{"read"}},
+			},
+			"/service/rest/v1/security/users": {
+				{"userId": "admin", "status": "disabled", "roles": []interface{}{"nx-admin"}},
+			},
+			"/service/rest/v1/security/content-selectors": {
+				{"name": "docs", "type": "csel", "expression": "path =^ \"/doc/\""},
+			},
+		})
+
+		var passwordsFile = filepath.Join(t.TempDir(), "passwords")
+		if tt.cfg.UserPasswords == "generate" {
+			tt.cfg.UserPasswordsFile = passwordsFile
+		}
+
+		var cloner = New(&Options{Source: srcServer.URL, Destination: dstServer.URL})
+		var clone = cloner.CloneConfig
+		if tt.security {
+			clone = cloner.CloneSecurity
+		}
+
+		if e := clone(context.Background(), &tt.cfg); e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		if !reflect.DeepEqual(dstServer.writes, tt.writes) {
+			t.Errorf("%s: writes: got %q, want %q", tt.name, dstServer.writes, tt.writes)
+		}
+
+		if len(srcServer.writes) != 0 {
+			t.Errorf("%s: source has been changed: %q", tt.name, srcServer.writes)
+		}
+
+		for idx, payload := range dstServer.payloads {
+			// read only fields and the key of PUT requests are not sent
+			for _, field := range []string{"source", "readOnly", "type"} {
+				if _, ok := payload[field]; ok {
+					t.Errorf("%s: %s has read only field %q", tt.name, dstServer.writes[idx], field)
+				}
+			}
+
+			if _, ok := payload["name"]; ok && strings.HasPrefix(dstServer.writes[idx], "PUT /service/rest/v1/security/content-selectors/") {
+				t.Errorf("%s: %s has name field", tt.name, dstServer.writes[idx])
+			}
+		}
+
+		data, e := ioutil.ReadFile(passwordsFile)
+		if len(tt.users) == 0 {
+			if !os.IsNotExist(e) {
+				t.Errorf("%s: passwords file: got %q, %v, want no file", tt.name, data, e)
+			}
+			continue
+		}
+
+		var users []string
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			var fields = strings.SplitN(line, ":", 2)
+			users = append(users, fields[0])
+
+			// the written password must be the created one
+			var created bool
+			for _, payload := range dstServer.payloads {
+				created = created || len(fields) == 2 && payload.getString("userId") == fields[0] && payload.getString("password") == fields[1]
+			}
+
+			if !created {
+				t.Errorf("%s: user %q has not been created with the written password", tt.name, fields[0])
+			}
+		}
+
+		if !reflect.DeepEqual(users, tt.users) {
+			t.Errorf("%s: passwords file users: got %q, want %q", tt.name, users, tt.users)
+		}
+	}
+}
+
+func TestCloneSecurityOptions(t *testing.T) {
+	var tests = []struct {
+		name string
+		cfg  ConfigOptions
+		e    error
+	}{
+		{"unknown item", ConfigOptions{Items: "users,groups", UserPasswords: "skip"}, errCfgUnknownKind},
+		{"invalid passwords mode", ConfigOptions{Items: "users", UserPasswords: "random"}, errSecInvPasswords},
+		{"generated passwords without file", ConfigOptions{Items: "users", UserPasswords: "generate"}, errSecNoPassFile},
+	}
+
+	for _, tt := range tests {
+		if e := New(&Options{}).CloneSecurity(context.Background(), &tt.cfg); e != tt.e {
+			t.Errorf("%s: got %v, want %v", tt.name, e, tt.e)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cloner
+
+import (
+	"io"
+	"net/url"
+	"strings"
+)
+
+type (
+	// Source is the repository endpoint which assets are cloned from.
+	Source interface {
+		ListAssets() ([]*NexusAsset, error)
+		OpenAsset(asset *NexusAsset) (io.ReadCloser, error)
+	}
+
+	// Destination is the repository endpoint which assets are cloned to.
+	Destination interface {
+		ListAssets() ([]*NexusAsset, error)
+		WriteAsset(asset *NexusAsset, r io.Reader) error
+		DeleteAsset(asset *NexusAsset) error
+	}
+)
+
+// newSource returns the source endpoint for the given argument by its scheme
+func newSource(s *session, arg string) (Source, error) {
+	endpoint, e := url.Parse(arg)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case endpoint.Scheme == "file":
+		return newFilesystem(s).initiate(arg)
+	case strings.HasPrefix(endpoint.Scheme, "maven+"):
+		return newMavenMirror(s).initiate(arg)
+	case strings.HasPrefix(endpoint.Scheme, "artifactory+"):
+		return newArtifactory(s).initiate(arg)
+	case strings.HasPrefix(endpoint.Scheme, "nexus2+"):
+		return newNexus2(s).initiate(arg)
+	}
+
+	src, e := newNexus(s, "src").initiate(arg, s.opts.SrcBasePath)
+	if e != nil {
+		return nil, e
+	}
+
+	return src, src.resolveGroupMembers()
+}
+
+// newDestination returns the destination endpoint for the given argument by its scheme
+func newDestination(s *session, arg string) (Destination, error) {
+	endpoint, e := url.Parse(arg)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case endpoint.Scheme == "file":
+		return newFilesystem(s).initiate(arg)
+	case strings.Contains(endpoint.Scheme, "+"):
+		return nil, errRepoReadOnly
+	}
+
+	return newNexus(s, "dst").initiate(arg, s.opts.DstBasePath)
+}
@@ -0,0 +1,183 @@
+package cloner
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	errFsInvGivArg = errors.New("There is some problems with parsing you filesystem endpoint. Use file:///path/to/maven-repo format.")
+	errFsNotDir    = errors.New("Given filesystem endpoint is not a directory.")
+)
+
+// filesystem is the local maven2 layout tree (~/.m2/repository or unpacked repository)
+// which can be used as a source or as a destination instead of Nexus repository.
+type filesystem struct {
+	root, repository, path string
+
+	*session
+}
+
+func newFilesystem(s *session) *filesystem {
+	return &filesystem{session: s}
+}
+
+// schema: file:///home/user/.m2/repository
+func (m *filesystem) initiate(arg string) (*filesystem, error) {
+	endpoint, e := url.Parse(arg)
+	if e != nil {
+		return nil, e
+	}
+
+	if len(endpoint.Host) != 0 && endpoint.Host != "localhost" {
+		return nil, errFsInvGivArg
+	}
+
+	m.root = endpoint.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/repository has "/C:/repository" path
+		m.root = strings.TrimPrefix(m.root, "/")
+	}
+
+	if len(m.root) == 0 {
+		return nil, errFsInvGivArg
+	}
+
+	m.root = filepath.Clean(filepath.FromSlash(m.root))
+	m.repository, m.path = filepath.Base(m.root), m.opts.PathFilter
+
+	m.log.Debug().Str("root", m.root).Str("repo", m.repository).Str("path", m.path).Msg("testing given filesystem repo/path")
+	return m, nil
+}
+
+func (m *filesystem) ListAssets() (assets []*NexusAsset, e error) {
+	var r *regexp.Regexp
+	if r, e = regexp.Compile(m.path); e != nil {
+		return
+	}
+
+	var info os.FileInfo
+	if info, e = os.Stat(m.root); e != nil {
+		if errors.Is(e, os.ErrNotExist) {
+			m.log.Warn().Str("root", m.root).Msg("Given filesystem repository is not exists. It will be created on the upload.")
+			return nil, nil
+		}
+		return
+	} else if !info.IsDir() {
+		return nil, errFsNotDir
+	}
+
+	e = filepath.Walk(m.root, func(fpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		var rel string
+		if rel, err = filepath.Rel(m.root, fpath); err != nil {
+			return err
+		}
+
+		var asset = &NexusAsset{
+			DownloadURL: (&url.URL{Scheme: "file", Path: filepath.ToSlash(fpath)}).String(),
+			Path:        filepath.ToSlash(rel),
+			ID:          filepath.ToSlash(rel),
+			Repository:  m.repository,
+			Format:      "maven2",
+			FileSize:    info.Size(),
+		}
+
+		if !filterMaven2Asset(asset, r, m.log) {
+			return nil
+		}
+
+		assets = append(assets, asset)
+		return nil
+	})
+
+	if e != nil {
+		return nil, e
+	}
+
+	m.log.Info().Int("count", len(assets)).Msg("Successfully parsed filesystem repository assets")
+	return
+}
+
+func (m *filesystem) getAssetPath(asset *NexusAsset) (string, error) {
+	return joinRelativePath(m.root, asset.Path)
+}
+
+func (m *filesystem) OpenAsset(asset *NexusAsset) (io.ReadCloser, error) {
+	fpath, e := m.getAssetPath(asset)
+	if e != nil {
+		return nil, e
+	}
+
+	return os.Open(fpath)
+}
+
+// WriteAsset writes the asset to the maven2 layout with sha1 and md5 checksum files
+func (m *filesystem) WriteAsset(asset *NexusAsset, src io.Reader) (e error) {
+	var fpath string
+	if fpath, e = m.getAssetPath(asset); e != nil {
+		return
+	}
+
+	if e = os.MkdirAll(filepath.Dir(fpath), 0755); e != nil {
+		return
+	}
+
+	var dst *os.File
+	if dst, e = os.Create(fpath); e != nil {
+		return
+	}
+	defer dst.Close()
+
+	var sha1sum, md5sum = sha1.New(), md5.New()
+	if _, e = io.Copy(io.MultiWriter(dst, sha1sum, md5sum), src); e != nil {
+		// partial files (interrupted or corrupted streams) must not be left in the repository
+		os.Remove(fpath)
+		return
+	}
+
+	for ext, sum := range map[string]hash.Hash{".sha1": sha1sum, ".md5": md5sum} {
+		if e = ioutil.WriteFile(fpath+ext, []byte(hex.EncodeToString(sum.Sum(nil))), 0644); e != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (m *filesystem) DeleteAsset(asset *NexusAsset) (e error) {
+	var fpath string
+	if fpath, e = m.getAssetPath(asset); e != nil {
+		return
+	}
+
+	if e = os.Remove(fpath); e != nil {
+		return
+	}
+
+	for _, ext := range []string{".sha1", ".md5"} {
+		if e = os.Remove(fpath + ext); e != nil && !errors.Is(e, os.ErrNotExist) {
+			return
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package cloner
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestFilesystem(t *testing.T, root string, opts *Options) *filesystem {
+	repo, e := newFilesystem(newTestSession(opts)).initiate((&url.URL{Scheme: "file", Path: filepath.ToSlash(root)}).String())
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	return repo
+}
+
+func TestFilesystemListAssets(t *testing.T) {
+	var root = filepath.Join(t.TempDir(), "repository")
+
+	// ~/.m2/repository tree after maven builds with failed and interrupted resolutions
+	var files = []string{
+		"org/example/lib/1.0/lib-1.0.jar",
+		"org/example/lib/1.0/lib-1.0.jar.sha1",
+		"org/example/lib/1.0/lib-1.0.pom",
+		"org/example/lib/1.0/lib-1.0.pom.sha1",
+		"org/example/lib/1.0/lib-1.0-sources.jar",
+		"org/example/lib/1.0/lib-1.0.jar.lastUpdated",
+		"org/example/lib/1.0/lib-1.0-sources.jar.lastUpdated",
+		"org/example/lib/1.0/lib-1.0-javadoc.jar.lastUpdated",
+		"org/example/lib/1.0/lib-1.0.jar.part",
+		"org/example/lib/1.0/lib-1.0.jar.part.lock",
+		"org/example/lib/1.0/lib-1.0.jar.lock",
+		"org/example/lib/1.0/_remote.repositories",
+		"org/example/lib/1.0/_maven.repositories",
+		"org/example/lib/1.1-SNAPSHOT/lib-1.1-SNAPSHOT.jar",
+		"org/example/lib/1.1-SNAPSHOT/maven-metadata-local.xml",
+		"org/example/lib/1.1-SNAPSHOT/resolver-status.properties",
+		"org/example/lib/maven-metadata-central.xml",
+		"org/example/lib/resolver-status.properties",
+		"org/example/tool/2.0/tool-2.0.war",
+		"org/example/tool/2.0/tool-2.0-linux.tar.gz",
+		"org/example/tool/2.0/tool-2.0.tar.gz.part",
+	}
+
+	for _, file := range files {
+		var fpath = filepath.Join(root, filepath.FromSlash(file))
+		if e := os.MkdirAll(filepath.Dir(fpath), 0755); e != nil {
+			t.Fatal(e)
+		}
+
+		if e := ioutil.WriteFile(fpath, []byte(file), 0644); e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	var repo = newTestFilesystem(t, root, &Options{})
+	if repo.repository != "repository" {
+		t.Errorf("repository: got %q, want %q", repo.repository, "repository")
+	}
+
+	assets, e := repo.ListAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var paths []string
+	var index = make(map[string]*NexusAsset)
+	for _, asset := range assets {
+		paths = append(paths, asset.Path)
+		index[asset.Path] = asset
+	}
+	sort.Strings(paths)
+
+	var want = []string{
+		"org/example/lib/1.0/lib-1.0-sources.jar",
+		"org/example/lib/1.0/lib-1.0.jar",
+		"org/example/lib/1.1-SNAPSHOT/lib-1.1-SNAPSHOT.jar",
+		"org/example/tool/2.0/tool-2.0-linux.tar.gz",
+		"org/example/tool/2.0/tool-2.0.war",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("assets: got %q, want %q", paths, want)
+	}
+
+	var asset = index["org/example/tool/2.0/tool-2.0-linux.tar.gz"]
+	if want := (NexuAssetMaven2{GroupID: "org.example", ArtifactID: "tool", Version: "2.0", Extension: "tar.gz", Classifier: "linux"}); *asset.Maven2 != want {
+		t.Errorf("maven2 coordinates: got %+v, want %+v", asset.Maven2, want)
+	}
+
+	if asset.FileSize != int64(len(asset.Path)) || asset.Repository != "repository" || !strings.HasPrefix(asset.DownloadURL, "file://") {
+		t.Errorf("asset: got %+v", asset)
+	}
+
+	body, e := repo.OpenAsset(asset)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer body.Close()
+
+	if data, e := ioutil.ReadAll(body); e != nil || string(data) != asset.Path {
+		t.Errorf("asset data: got %q, %v", data, e)
+	}
+}
+
+func TestFilesystemListAssetsMissingRoot(t *testing.T) {
+	assets, e := newTestFilesystem(t, filepath.Join(t.TempDir(), "missing"), &Options{}).ListAssets()
+	if e != nil || len(assets) != 0 {
+		t.Errorf("got %d assets and error %v, want the empty repository", len(assets), e)
+	}
+}
+
+func TestFilesystemWriteAsset(t *testing.T) {
+	var repo = newTestFilesystem(t, t.TempDir(), &Options{})
+	var asset = &NexusAsset{Path: "org/example/lib/1.0/lib-1.0.jar"}
+
+	if e := repo.WriteAsset(asset, strings.NewReader("data")); e != nil {
+		t.Fatal(e)
+	}
+
+	// checksum files are written with the asset, but they are not listed
+	for ext, sum := range map[string]string{"": "data", ".sha1": "a17c9aaa61e80a1bf71d0d850af4e5baa9800bbd", ".md5": "8d777f385d3dfec8815d20f7496026dc"} {
+		data, e := ioutil.ReadFile(filepath.Join(repo.root, filepath.FromSlash(asset.Path+ext)))
+		if e != nil || string(data) != sum {
+			t.Errorf("%s: got %q, %v, want %q", asset.Path+ext, data, e, sum)
+		}
+	}
+
+	assets, e := repo.ListAssets()
+	if e != nil || len(assets) != 1 || assets[0].Path != asset.Path {
+		t.Errorf("got %d assets and error %v, want the written asset only", len(assets), e)
+	}
+
+	if e = repo.WriteAsset(&NexusAsset{Path: "../escape.jar"}, strings.NewReader("data")); e != errStInvPath {
+		t.Errorf("asset outside of the repository: got %v, want %v", e, errStInvPath)
+	}
+}
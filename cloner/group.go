@@ -0,0 +1,140 @@
+package cloner
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errGrpInvMode  = errors.New("There is invalid value in --group-mode option. Option supports flatten and members values.")
+	errGrpNoNexus  = errors.New("Group members synchronization is supported for Nexus destination only.")
+	errGrpNoConfig = errors.New("Could not get group members from the repository configuration.")
+)
+
+// resolveGroupMembers detects group repository and resolves its members (nested groups are expanded).
+// If the group could not be resolved, it's listed with the search api as any other repository.
+func (m *nexus) resolveGroupMembers() (e error) {
+	var repositories []*NexusRepository
+	if repositories, e = m.getRepositories(); e != nil {
+		m.log.Warn().Err(e).Str("repo", m.repository).Msg("Could not get the repository type. Group detection will be skipped.")
+		return nil
+	}
+
+	var index = make(map[string]*NexusRepository, len(repositories))
+	for _, repository := range repositories {
+		index[repository.Name] = repository
+	}
+
+	if repository, ok := index[m.repository]; !ok || repository.Type != "group" {
+		return nil
+	}
+
+	// group configuration api requires admin permissions, read-only users get the group assets with the search api
+	if m.members, e = m.getGroupMembers(m.repository, index, make(map[string]bool)); e != nil {
+		m.log.Warn().Err(e).Str("repo", m.repository).Msg("Could not resolve the group members. The group will be synchronized as a single repository.")
+		m.members = nil
+		return nil
+	}
+
+	var names []string
+	for _, member := range m.members {
+		names = append(names, member.Name)
+	}
+
+	m.log.Info().Str("repo", m.repository).Strs("members", names).Msg("Given repository is a group. Its members have been resolved.")
+	return
+}
+
+func (m *nexus) getGroupMembers(name string, index map[string]*NexusRepository, visited map[string]bool) (members []*NexusRepository, e error) {
+	if visited[name] {
+		return
+	}
+	visited[name] = true
+
+	var config map[string]interface{}
+	if config, e = m.getRepositoryConfig(index[name]); e != nil {
+		return
+	}
+
+	group, _ := config["group"].(map[string]interface{})
+	names, ok := group["memberNames"].([]interface{})
+	if !ok {
+		m.log.Error().Str("repo", name).Msg("Group repository configuration has no members")
+		return nil, errGrpNoConfig
+	}
+
+	for _, buf := range names {
+		member, ok := index[buf.(string)]
+		if !ok {
+			m.log.Warn().Str("repo", name).Str("member", buf.(string)).Msg("Could not find the group member. It will be skipped!")
+			continue
+		}
+
+		if member.Type != "group" {
+			members = append(members, member)
+			continue
+		}
+
+		var nested []*NexusRepository
+		if nested, e = m.getGroupMembers(member.Name, index, visited); e != nil {
+			return
+		}
+
+		members = append(members, nested...)
+	}
+
+	return
+}
+
+// getGroupAssets returns assets of all group members. If the same path is found in several members,
+// the asset of the first member is used as Nexus does it for group requests.
+func (m *nexus) getGroupAssets() (assets []*NexusAsset, e error) {
+	var paths = make(map[string]bool)
+
+	for _, member := range m.members {
+		var buf []*NexusAsset
+		if buf, e = m.getRepositoryNexus(member.Name).ListAssets(); e != nil {
+			m.log.Error().Err(e).Str("repo", m.repository).Str("member", member.Name).Msg("Could not get the group member assets")
+			return nil, e
+		}
+
+		for _, asset := range buf {
+			if paths[asset.Path] {
+				m.log.Debug().Str("path", asset.Path).Str("member", member.Name).Msg("The asset is shadowed by another group member. It will be skipped!")
+				continue
+			}
+
+			paths[asset.Path] = true
+			assets = append(assets, asset)
+		}
+	}
+
+	m.log.Info().Str("repo", m.repository).Int("count", len(assets)).Msg("Successfully parsed group members assets")
+	return
+}
+
+// syncGroupMembers synchronizes every hosted member of the source group with the destination
+// repository named by --repo-rename template
+func (m *Cloner) syncGroupMembers(src *nexus) (*Report, error) {
+	dst, ok := m.dst.(*nexus)
+	if !ok {
+		return nil, errGrpNoNexus
+	}
+
+	var cloners []*Cloner
+	for _, member := range src.members {
+		if member.Type != "hosted" {
+			m.log.Info().Str("member", member.Name).Str("type", member.Type).Msg("Group member is not hosted. It will be skipped!")
+			continue
+		}
+
+		var dstRepository = strings.ReplaceAll(m.opts.RepoRename, "{repo}", member.Name)
+		cloners = append(cloners, m.newRepositoryCloner(src.getRepositoryNexus(member.Name),
+			dst.getRepositoryNexus(dstRepository), &RepositoryReport{Source: member.Name, Destination: dstRepository}))
+	}
+
+	m.log.Info().Str("repo", src.repository).Int("count", len(cloners)).Msg("Starting synchronization of group members")
+	m.runCloners(cloners)
+
+	return m.finish(cloners)
+}
@@ -0,0 +1,97 @@
+package cloner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newTestGroupServer serves the public group of releases and snapshots repositories.
+// The group configuration is returned with the given status (admin permissions are required for it).
+func newTestGroupServer(t *testing.T, configStatus int) *httptest.Server {
+	var assets = map[string][]*NexusAsset{
+		"public":    {{Path: "org/a/1.0/a-1.0.jar"}, {Path: "org/b/1.0-SNAPSHOT/b-1.0-SNAPSHOT.jar"}},
+		"releases":  {{Path: "org/a/1.0/a-1.0.jar"}, {Path: "org/a/1.0/a-1.0.pom"}},
+		"snapshots": {{Path: "org/b/1.0-SNAPSHOT/b-1.0-SNAPSHOT.jar"}, {Path: "org/a/1.0/a-1.0.jar"}},
+	}
+
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/service/rest/v1/repositories":
+			json.NewEncoder(w).Encode([]*NexusRepository{
+				{Name: "public", Format: "maven2", Type: "group"},
+				{Name: "releases", Format: "maven2", Type: "hosted"},
+				{Name: "snapshots", Format: "maven2", Type: "hosted"},
+			})
+		case "/service/rest/v1/repositories/maven/group/public":
+			if configStatus != http.StatusOK {
+				w.WriteHeader(configStatus)
+				return
+			}
+
+			w.Write([]byte(`{"name":"public","group":{"memberNames":["releases","snapshots"]}}`))
+		case "/service/rest/v1/search/assets":
+			items, ok := assets[r.URL.Query().Get("repository")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			json.NewEncoder(w).Encode(&NexusAssetsCollection{Items: items})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNexusGroupSource(t *testing.T) {
+	var tests = []struct {
+		name         string
+		configStatus int
+		members      []string
+	}{
+		{"resolved members", http.StatusOK, []string{"releases", "snapshots"}},
+		{"forbidden group configuration", http.StatusForbidden, nil},
+		{"missing group configuration", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		var server = newTestGroupServer(t, tt.configStatus)
+
+		src, e := newSource(newTestSession(&Options{}), server.URL+"/repository/public")
+		if e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		var members []string
+		for _, member := range src.(*nexus).members {
+			members = append(members, member.Name)
+		}
+
+		if !reflect.DeepEqual(members, tt.members) {
+			t.Errorf("%s: members: got %q, want %q", tt.name, members, tt.members)
+		}
+
+		assets, e := src.ListAssets()
+		if e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		var paths []string
+		for _, asset := range assets {
+			paths = append(paths, asset.Path)
+		}
+
+		// the group is flattened by members or it's listed with the search api
+		if want := []string{"org/a/1.0/a-1.0.jar", "org/b/1.0-SNAPSHOT/b-1.0-SNAPSHOT.jar"}; !reflect.DeepEqual(paths, want) {
+			t.Errorf("%s: assets: got %q, want %q", tt.name, paths, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cloner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newTestMavenServer serves the static maven2 tree, directories are given with the trailing slash
+func newTestMavenServer(t *testing.T, tree map[string]string) *httptest.Server {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := tree[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(data))
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMavenParseDirectoryListing(t *testing.T) {
+	var dir, _ = url.Parse("https://repo.example.com/maven2/org/example/")
+	var listing = `<html><body>
+<a href="?C=N;O=D">Name</a> <a href="?C=M;O=A">Last modified</a>
+<a href="../">Parent Directory</a>
+<a href="/maven2/org/">Parent absolute</a>
+<a href="lib/">lib/</a>
+<a HREF = 'util/'>util/</a>
+<a href="https://repo.example.com/maven2/org/example/tools/">tools/</a>
+<a href="https://other.example.com/maven2/org/example/other/">other/</a>
+<a href="//other.example.com/maven2/org/example/cdn/">cdn/</a>
+<a href="maven-metadata.xml">maven-metadata.xml</a>
+<a href="maven-metadata.xml">maven-metadata.xml</a>
+<a href="lib/1.0/">nested/</a>
+<a href="lib/?C=S;O=A">lib sorted</a>
+<a href="/maven2/org/example/">current</a>
+</body></html>`
+
+	dirs, files := newMavenMirror(newTestSession(&Options{})).parseDirectoryListing(dir, []byte(listing))
+
+	if want := []string{"lib/", "util/", "tools/"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs: got %q, want %q", dirs, want)
+	}
+
+	if want := []string{"maven-metadata.xml"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("files: got %q, want %q", files, want)
+	}
+}
+
+func TestMavenListAssets(t *testing.T) {
+	var server = newTestMavenServer(t, map[string]string{
+		"/maven2/":                     `<a href="../">../</a> <a href="org/">org/</a> <a href="?C=N;O=D">Name</a>`,
+		"/maven2/org/":                 `<a href="../">../</a> <a href="example/">example/</a>`,
+		"/maven2/org/example/":         `<a href="../">../</a> <a href="lib/">lib/</a> <a href="missing/">missing/</a>`,
+		"/maven2/org/example/lib/":     `<a href="1.0/">1.0/</a> <a href="maven-metadata.xml">maven-metadata.xml</a>`,
+		"/maven2/org/example/lib/1.0/": `<a href="lib-1.0.jar">lib-1.0.jar</a> <a href="lib-1.0.jar.sha1">lib-1.0.jar.sha1</a> <a href="lib-1.0-sources.jar">lib-1.0-sources.jar</a> <a href="lib-1.0.pom">lib-1.0.pom</a> <a href="readme.txt">readme.txt</a>`,
+		// the version is not listed in the parent directory, it's found by maven-metadata.xml only
+		"/maven2/org/example/lib/2.0-SNAPSHOT/": `<a href="lib-2.0-20211020.101010-1.jar">lib-2.0-20211020.101010-1.jar</a>`,
+		"/maven2/org/example/lib/maven-metadata.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<metadata><groupId>org.example</groupId><artifactId>lib</artifactId>
+<versioning><versions><version>1.0</version><version>2.0-SNAPSHOT</version><version>3.0</version></versions></versioning>
+</metadata>`,
+	})
+
+	mirror, e := newMavenMirror(newTestSession(&Options{})).initiate("maven+" + server.URL + "/maven2")
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if mirror.repository != "maven2" {
+		t.Errorf("repository: got %q, want %q", mirror.repository, "maven2")
+	}
+
+	assets, e := mirror.ListAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var got = make(map[string]*NexusAsset)
+	for _, asset := range assets {
+		got[asset.Path] = asset
+	}
+
+	var paths = make([]string, 0, len(got))
+	for p := range got {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var want = []string{
+		"org/example/lib/1.0/lib-1.0-sources.jar",
+		"org/example/lib/1.0/lib-1.0.jar",
+		"org/example/lib/2.0-SNAPSHOT/lib-2.0-20211020.101010-1.jar",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("assets: got %q, want %q", paths, want)
+	}
+
+	var asset = got["org/example/lib/1.0/lib-1.0-sources.jar"]
+	if asset.DownloadURL != server.URL+"/maven2/org/example/lib/1.0/lib-1.0-sources.jar" {
+		t.Errorf("download url: got %q", asset.DownloadURL)
+	}
+
+	if asset.Repository != "maven2" || asset.Format != "maven2" {
+		t.Errorf("repository and format: got %q, %q", asset.Repository, asset.Format)
+	}
+
+	if want := (NexuAssetMaven2{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Extension: "jar", Classifier: "sources"}); asset.Maven2 == nil || *asset.Maven2 != want {
+		t.Errorf("maven2 coordinates: got %+v, want %+v", asset.Maven2, want)
+	}
+}
+
+func TestMavenListAssetsPathFilter(t *testing.T) {
+	var server = newTestMavenServer(t, map[string]string{
+		"/":           `<a href="org/">org/</a>`,
+		"/org/":       `<a href="a/">a/</a>`,
+		"/org/a/":     `<a href="1.0/">1.0/</a>`,
+		"/org/a/1.0/": `<a href="a-1.0.jar">a-1.0.jar</a> <a href="a-1.0-sources.jar">a-1.0-sources.jar</a> <a href="a-1.0.pom">a-1.0.pom</a>`,
+	})
+
+	mirror, e := newMavenMirror(newTestSession(&Options{PathFilter: `-sources\.jar$`})).initiate("maven+" + server.URL)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if !strings.HasPrefix(mirror.repository, "127.0.0.1") {
+		t.Errorf("repository of the root mirror must be the hostname, got %q", mirror.repository)
+	}
+
+	assets, e := mirror.ListAssets()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	if len(assets) != 1 || assets[0].Path != "org/a/1.0/a-1.0-sources.jar" {
+		t.Errorf("assets: got %d, want the sources file only", len(assets))
+	}
+}
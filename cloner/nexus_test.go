@@ -0,0 +1,138 @@
+package cloner
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNexusInitiate(t *testing.T) {
+	var tests = []struct {
+		arg, basePath          string
+		base, repository, path string
+	}{
+		{"https://h/releases/org/apache", "", "", "releases", "org/apache"},
+		{"https://h/repository/releases/org/apache", "", "", "releases", "org/apache"},
+		{"https://h/nexus/repository/releases/org/apache", "", "/nexus", "releases", "org/apache"},
+		{"https://h/releases/org/apache/repository/x", "", "", "releases", "org/apache/repository/x"},
+		{"https://h/apps/nexus/repository/releases", "", "", "apps", "nexus/repository/releases"},
+		{"https://h/apps/nexus/repository/releases/org", "/apps/nexus", "/apps/nexus", "releases", "org"},
+		{"https://h/nexus/releases/org/repository/x", "nexus/", "/nexus", "releases", "org/repository/x"},
+		{"https://h/repository/", "", "", "repository", ".*"},
+	}
+
+	for _, tt := range tests {
+		nexus, e := newNexus(newTestSession(&Options{}), "src").initiate(tt.arg, tt.basePath)
+		if e != nil {
+			t.Errorf("%s: unexpected error %v", tt.arg, e)
+			continue
+		}
+
+		var got = []string{nexus.basePath, nexus.repository, nexus.path}
+		if want := []string{tt.base, tt.repository, tt.path}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s (base %q): got %q, want %q", tt.arg, tt.basePath, got, want)
+		}
+	}
+}
+
+func TestNexusInitiateInvalidBasePath(t *testing.T) {
+	for _, arg := range []string{"https://h/other/releases", "https://h/nexus"} {
+		if _, e := newNexus(newTestSession(&Options{}), "src").initiate(arg, "/nexus"); e != errInvGivArg {
+			t.Errorf("%s: got %v, want %v", arg, e, errInvGivArg)
+		}
+	}
+}
+
+// failingReader returns the error after the data end
+type failingReader struct {
+	io.Reader
+	e error
+}
+
+func (m *failingReader) Read(p []byte) (n int, e error) {
+	if n, e = m.Reader.Read(p); e == io.EOF {
+		e = m.e
+	}
+	return
+}
+
+// zeroReader is the endless source of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestNexusStreamAsset(t *testing.T) {
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	var errSource = errors.New("source error")
+
+	var tests = []struct {
+		name    string
+		handler http.HandlerFunc
+		source  io.Reader
+		failed  bool
+	}{
+		{"uploaded", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/service/rest/v1/components" || r.URL.Query().Get("repository") != "releases" {
+				http.NotFound(w, r)
+				return
+			}
+
+			file, _, e := r.FormFile("asset0")
+			if e != nil {
+				http.Error(w, e.Error(), http.StatusBadRequest)
+				return
+			}
+			defer file.Close()
+
+			buf, _ := ioutil.ReadAll(file)
+			if !bytes.Equal(buf, data) || r.FormValue("groupId") != "org.example" || r.FormValue("asset0.classifier") != "sources" {
+				http.Error(w, "unexpected form", http.StatusBadRequest)
+				return
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}, bytes.NewReader(data), false},
+		{"source error", func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}, &failingReader{Reader: bytes.NewReader(data), e: errSource}, true},
+		{"response before the body end", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}, io.LimitReader(zeroReader{}, 1<<30), true},
+		{"rejected", func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusBadRequest)
+		}, bytes.NewReader(data), true},
+	}
+
+	for _, tt := range tests {
+		var server = httptest.NewServer(tt.handler)
+
+		dst, e := newNexus(newTestSession(&Options{}), "dst").initiate(server.URL+"/releases", "")
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var asset = &NexusAsset{
+			Path:     "org/example/lib/1.0/lib-1.0-sources.jar",
+			FileSize: int64(len(data)),
+			Maven2:   getMaven2FromPath("org/example/lib/1.0/lib-1.0-sources.jar"),
+		}
+
+		if e = dst.StreamAsset(asset, tt.source); (e != nil) != tt.failed {
+			t.Errorf("%s: got error %v, want failed %v", tt.name, e, tt.failed)
+		}
+
+		server.Close()
+	}
+}
@@ -0,0 +1,143 @@
+package cloner
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Options are the synchronization options. Every option is equivalent to the command line flag
+// with the similar name. Zero values of timeouts and limits disable them.
+type Options struct {
+	Logger *zerolog.Logger // logging is disabled if it's nil
+
+	// Source and Destination are the endpoint arguments (repository urls or paths).
+	// They are Nexus server urls if All or RepoRegex is set.
+	Source, Destination string
+
+	SrcBasePath, DstBasePath string
+	PathFilter               string // regexp of the synchronized asset paths (all paths if empty)
+	DownloadURLRewrite       []string
+	DownloadURLRebase        bool
+
+	All                bool
+	RepoRegex          string
+	RepoRename         string // destination repository name template, {repo} is the source repository name
+	RepoConcurrency    int
+	GroupMode          string // flatten (default) or members
+	WarmProxy          bool
+	CreateMissingRepos bool
+	BlobStoreMap       []string
+
+	SkipDownload       bool
+	SkipDownloadErrors bool
+	SkipUpload         bool
+	Stream             bool
+	CacheDir           string
+	CacheMaxSize       int64
+
+	TempPathPrefix           string
+	TempPathSave             bool
+	ProcessContinueDirectory string // staging directory of the interrupted run, its partial files are continued
+	MaxStagingBytes          int64
+
+	HTTPClientTimeout   time.Duration // api request timeout
+	HTTPConnectTimeout  time.Duration
+	HTTPTLSTimeout      time.Duration
+	HTTPHeaderTimeout   time.Duration
+	HTTPIdleTimeout     time.Duration
+	HTTPTransferTimeout time.Duration
+	HTTPTransferMinRate int64
+	HTTPClientInsecure  bool
+	HTTPRetryAttempts   int
+	HTTPRetryDelay      time.Duration
+	HTTPRetryMaxDelay   time.Duration
+
+	SrcRate, DstRate                 float64
+	MaxBandwidth                     int64
+	SrcMaxBandwidth, DstMaxBandwidth int64
+
+	ShutdownTimeout time.Duration
+	StateFile       string // progress state is not written if it's empty
+}
+
+// ConfigOptions are the options of the configuration and security clone
+type ConfigOptions struct {
+	Items             string // comma separated list of the cloned items
+	DryRun            bool
+	UpdateExisting    bool   // existing items which differ are not updated without it
+	UserPasswords     string // skip or generate (security only)
+	UserPasswordsFile string
+}
+
+// session keeps the options, the logger and the requests context, they are shared by all cloner components
+type session struct {
+	ctx  context.Context // it's cancelled after --shutdown-timeout
+	opts *Options
+	log  *zerolog.Logger
+}
+
+// getOptions returns the copy of the given options with defaults for empty values (nil is the default options)
+func getOptions(opts *Options) *Options {
+	var buf Options
+	if opts != nil {
+		buf = *opts
+	}
+
+	if buf.Logger == nil {
+		var log = zerolog.Nop()
+		buf.Logger = &log
+	}
+
+	if len(buf.RepoRename) == 0 {
+		buf.RepoRename = "{repo}"
+	}
+
+	if len(buf.GroupMode) == 0 {
+		buf.GroupMode = "flatten"
+	}
+
+	if len(buf.PathFilter) == 0 {
+		buf.PathFilter = ".*"
+	}
+
+	return &buf
+}
+
+// getLimits returns limits of the given endpoint role (src or dst).
+// Endpoint bandwidth limit overrides the common MaxBandwidth value.
+func (m *Options) getLimits(role string) (rate float64, bandwidth int64) {
+	switch role {
+	case "src":
+		rate, bandwidth = m.SrcRate, m.SrcMaxBandwidth
+	case "dst":
+		rate, bandwidth = m.DstRate, m.DstMaxBandwidth
+	}
+
+	if bandwidth == 0 {
+		bandwidth = m.MaxBandwidth
+	}
+
+	return
+}
+
+// getTransferBandwidth returns the bandwidth of the single transfer of the given endpoint role (0 is unlimited).
+// The endpoint bandwidth is shared by all transfers, several repositories are synchronized concurrently
+// with --all, --repo-regex and members group mode.
+func (m *Options) getTransferBandwidth(role string) int64 {
+	var _, bandwidth = m.getLimits(role)
+	if bandwidth <= 0 {
+		return 0
+	}
+
+	if (m.All || len(m.RepoRegex) != 0 || m.GroupMode == "members") && m.RepoConcurrency > 1 {
+		bandwidth = bandwidth / int64(m.RepoConcurrency)
+	}
+
+	if bandwidth < 1 {
+		bandwidth = 1
+	}
+
+	return bandwidth
+}
@@ -0,0 +1,85 @@
+package cloner
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/url"
+)
+
+var (
+	errPrxNoWarmer = errors.New("Given destination endpoint could not be warmed. Use Nexus proxy repository as a destination for --warm-proxy.")
+	errPrxNotProxy = errors.New("Given destination repository is not a proxy repository.")
+)
+
+// proxyWarmer is the destination which caches assets from its remote on requests (Nexus proxy repository).
+// It's used with --warm-proxy instead of uploading.
+type proxyWarmer interface {
+	checkProxyRepository() error
+	WarmAsset(*NexusAsset) error
+}
+
+func (m *nexus) checkProxyRepository() (e error) {
+	var repository *NexusRepository
+	if repository, e = m.getRepository(m.repository); e != nil {
+		return
+	}
+
+	if repository == nil || repository.Type != "proxy" {
+		m.log.Error().Str("repo", m.repository).Msg("Destination repository is not found or it's not a proxy")
+		return errPrxNotProxy
+	}
+
+	return
+}
+
+// WarmAsset requests the asset through the proxy repository and discards the content,
+// so the proxy fetches it from its remote and keeps it in the cache
+func (m *nexus) WarmAsset(asset *NexusAsset) (e error) {
+	var rrl *url.URL
+	if rrl, e = m.getNexusURL("/repository/" + url.PathEscape(m.repository) + "/" + escapeRelativePath(asset.Path)); e != nil {
+		return
+	}
+
+	var body io.ReadCloser
+	if body, e = m.api.getNexusFile(rrl.String(), asset.FileSize); e != nil {
+		return
+	}
+	defer body.Close()
+
+	_, e = io.Copy(ioutil.Discard, body)
+	return
+}
+
+func (m *Cloner) warmMissingAssets(assets []*NexusAsset) (e error) {
+	warmer, ok := m.dst.(proxyWarmer)
+	if !ok {
+		return errPrxNoWarmer
+	}
+
+	if e = warmer.checkProxyRepository(); e != nil {
+		return
+	}
+
+	for i, asset := range assets {
+		if m.isInterrupted() {
+			return errClInterrupted
+		}
+
+		if err := warmer.WarmAsset(asset); err != nil {
+			m.log.Error().Err(err).Msgf("There is error while warming asset. Asset %s will be skipped.", asset.ID)
+			if m.isInterrupted() {
+				return errClInterrupted
+			}
+
+			m.report.Failed++
+			continue
+		}
+
+		m.report.Warmed++
+		m.report.complete(asset)
+		m.log.Info().Msgf("The asset %s has been warmed successfully. Remaining %d files", asset.getHumanReadbleName(), len(assets)-i-1)
+	}
+
+	return
+}
@@ -0,0 +1,146 @@
+package cloner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is the token bucket which is shared by all requests of the endpoint.
+// Tokens are reserved in advance, so concurrent waiters are served in the order of calls.
+type rateLimiter struct {
+	sync.Mutex
+
+	rate, burst, tokens float64
+	last                time.Time
+}
+
+// newRateLimiter returns the limiter for the given rate per second or nil if it's unlimited
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens (not more than burst) and returns the delay of their availability
+func (m *rateLimiter) reserve(n float64) time.Duration {
+	m.Lock()
+	defer m.Unlock()
+
+	var now = time.Now()
+	if m.tokens += now.Sub(m.last).Seconds() * m.rate; m.tokens > m.burst {
+		m.tokens = m.burst
+	}
+
+	m.last, m.tokens = now, m.tokens-n
+	if m.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-m.tokens / m.rate * float64(time.Second))
+}
+
+// wait blocks until n tokens are taken or the context is done
+func (m *rateLimiter) wait(ctx context.Context, n float64) error {
+	for n > 0 {
+		var take = n
+		if take > m.burst {
+			take = m.burst
+		}
+		n -= take
+
+		var delay = m.reserve(take)
+		if delay == 0 {
+			continue
+		}
+
+		var timer = time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// limitedBody limits the reading speed of the request or response body
+type limitedBody struct {
+	io.ReadCloser
+
+	ctx     context.Context
+	limiter *rateLimiter
+}
+
+func (m *limitedBody) Read(p []byte) (n int, e error) {
+	if n, e = m.ReadCloser.Read(p); n > 0 {
+		if err := m.limiter.wait(m.ctx, float64(n)); err != nil && e == nil {
+			e = err
+		}
+	}
+
+	return
+}
+
+// limitedTransport limits requests per second and bandwidth (uploads and downloads) of the endpoint
+type limitedTransport struct {
+	http.RoundTripper
+
+	requests, bandwidth *rateLimiter
+}
+
+// newLimitedTransport wraps the transport with limits of the given endpoint role (src or dst)
+func newLimitedTransport(transport http.RoundTripper, s *session, role string) http.RoundTripper {
+	var rate, bandwidth = s.opts.getLimits(role)
+	if rate <= 0 && bandwidth <= 0 {
+		return transport
+	}
+
+	s.log.Debug().Str("endpoint", role).Float64("rate", rate).Int64("bandwidth", bandwidth).Msg("endpoint limits have been set")
+
+	return &limitedTransport{
+		RoundTripper: transport,
+		requests:     newRateLimiter(rate, rate),
+		bandwidth:    newRateLimiter(float64(bandwidth), float64(bandwidth)),
+	}
+}
+
+func (m *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if m.requests != nil {
+		if e := m.requests.wait(req.Context(), 1); e != nil {
+			return nil, e
+		}
+	}
+
+	if m.bandwidth == nil {
+		return m.RoundTripper.RoundTrip(req)
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		req.Body = &limitedBody{ReadCloser: req.Body, ctx: req.Context(), limiter: m.bandwidth}
+	}
+
+	rsp, e := m.RoundTripper.RoundTrip(req)
+	if e != nil {
+		return nil, e
+	}
+
+	rsp.Body = &limitedBody{ReadCloser: rsp.Body, ctx: req.Context(), limiter: m.bandwidth}
+	return rsp, nil
+}
@@ -0,0 +1,134 @@
+package cloner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// testNexusServer is the fake nexus with repositories and cleanup policies api.
+// Created repositories are kept with their payloads.
+type testNexusServer struct {
+	*httptest.Server
+	sync.Mutex
+
+	repositories []*NexusRepository
+	configs      map[string]interface{} // repositories api path -> configuration (nil is forbidden)
+	policies     []nexusConfigItem      // nil is forbidden
+	created      map[string]interface{} // repositories api path -> payload
+}
+
+func newTestNexusServer(t *testing.T) *testNexusServer {
+	var server = &testNexusServer{configs: make(map[string]interface{}), created: make(map[string]interface{})}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Lock()
+		defer server.Unlock()
+
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/service/rest/v1/repositories":
+			json.NewEncoder(w).Encode(server.repositories)
+		case r.Method == "GET" && r.URL.Path == "/service/rest/v1/cleanup-policies":
+			if server.policies == nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			json.NewEncoder(w).Encode(server.policies)
+		case r.Method == "GET":
+			config, ok := server.configs[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			} else if config == nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			json.NewEncoder(w).Encode(config)
+		case r.Method == "POST":
+			var payload map[string]interface{}
+			if e := json.NewDecoder(r.Body).Decode(&payload); e != nil {
+				http.Error(w, e.Error(), http.StatusBadRequest)
+				return
+			}
+
+			server.created[r.URL.Path] = payload
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCreateRepositoryFrom(t *testing.T) {
+	var sourceConfig = map[string]interface{}{
+		"name":    "releases",
+		"online":  true,
+		"storage": map[string]interface{}{"strictContentTypeValidation": true, "writePolicy": "allow"},
+		"cleanup": map[string]interface{}{"policyNames": []interface{}{"weekly", "missing"}},
+		"maven":   map[string]interface{}{"versionPolicy": "RELEASE", "layoutPolicy": "STRICT"},
+	}
+
+	var tests = []struct {
+		name         string
+		config       map[string]interface{}
+		policies     []nexusConfigItem
+		blobStoreMap []string
+		cleanup      interface{}
+		storage      map[string]interface{}
+	}{
+		{"existing policies only", sourceConfig, []nexusConfigItem{{"name": "weekly"}, {"name": "other"}}, nil,
+			map[string]interface{}{"policyNames": []interface{}{"weekly"}},
+			map[string]interface{}{"blobStoreName": "default", "strictContentTypeValidation": true, "writePolicy": "allow"}},
+		{"no existing policies", sourceConfig, []nexusConfigItem{}, []string{"default=big"}, nil,
+			map[string]interface{}{"blobStoreName": "big", "strictContentTypeValidation": true, "writePolicy": "allow"}},
+		{"forbidden policies", sourceConfig, nil, nil, nil,
+			map[string]interface{}{"blobStoreName": "default", "strictContentTypeValidation": true, "writePolicy": "allow"}},
+		{"forbidden source configuration", nil, []nexusConfigItem{{"name": "weekly"}}, nil, nil,
+			map[string]interface{}{"blobStoreName": "default", "strictContentTypeValidation": true, "writePolicy": "allow_once"}},
+	}
+
+	for _, tt := range tests {
+		var srcServer, dstServer = newTestNexusServer(t), newTestNexusServer(t)
+		srcServer.repositories = []*NexusRepository{{Name: "releases", Format: "maven2", Type: "hosted"}}
+		srcServer.configs["/service/rest/v1/repositories/maven/hosted/releases"] = tt.config
+		dstServer.policies = tt.policies
+
+		var s = newTestSession(&Options{BlobStoreMap: tt.blobStoreMap})
+		src, e := newNexus(s, "src").initiate(srcServer.URL+"/releases", "")
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		dst, e := newNexus(s, "dst").initiate(dstServer.URL+"/releases-copy", "")
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		if e = dst.createRepositoryFrom(src); e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		payload, _ := dstServer.created["/service/rest/v1/repositories/maven/hosted"].(map[string]interface{})
+		if payload == nil || payload["name"] != "releases-copy" {
+			t.Errorf("%s: repository has not been created: %v", tt.name, dstServer.created)
+			continue
+		}
+
+		if cleanup, ok := payload["cleanup"]; !reflect.DeepEqual(cleanup, tt.cleanup) || ok != (tt.cleanup != nil) {
+			t.Errorf("%s: cleanup: got %v, want %v", tt.name, cleanup, tt.cleanup)
+		}
+
+		if !reflect.DeepEqual(payload["storage"], tt.storage) {
+			t.Errorf("%s: storage: got %v, want %v", tt.name, payload["storage"], tt.storage)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package cloner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var (
+	errRsmChanged = errors.New("Could not resume the download because the file has been changed on the server.")
+)
+
+// resumeAttempts is the number of resumes without any received data. It does not depend on
+// --http-retry-attempts, so downloads are resumed with the default (single attempt) retry policy too.
+const resumeAttempts = 5
+
+// stagingValidatorExt is the suffix of the file with the download validator of the staging file
+const stagingValidatorExt = ".validator"
+
+// assetResumer is the source which continues partial downloads from the given offset.
+// Sources without it are downloaded from the beginning.
+type assetResumer interface {
+	ResumeAsset(asset *NexusAsset, offset int64, validator string) (*resumableBody, error)
+}
+
+// resumableBody is the file download body which is continued with Range requests after
+// read errors (dropped connections, idle timeouts). The download is resumed only if the server
+// supports byte ranges and the file has a validator (strong ETag or Last-Modified) for If-Range.
+// All requests of the download share the transfer context with the transfer timeout.
+type resumableBody struct {
+	api           *nexusApi
+	url, redacted string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	body      io.ReadCloser
+	validator string
+
+	offset, resumedAt int64
+	attempts          int
+}
+
+// request sends the file request from the current offset, the response body is closed with the idle timeout
+func (m *resumableBody) request() (rsp *http.Response, e error) {
+	ctx, cancel := context.WithCancel(m.ctx)
+
+	var req *http.Request
+	if req, e = http.NewRequestWithContext(ctx, "GET", m.url, nil); e != nil {
+		cancel()
+		return
+	}
+
+	m.api.authorizeNexusRequest(req)
+	if m.offset != 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", m.offset))
+		req.Header.Set("If-Range", m.validator)
+	}
+
+	if rsp, e = m.api.doNexusRequest(req, 0, nil); e != nil {
+		cancel()
+		return
+	}
+
+	rsp.Body = newIdleBody(rsp.Body, m.api.timeouts.idle, cancel)
+	return
+}
+
+func (m *resumableBody) setResponse(rsp *http.Response) {
+	m.body, m.redacted = rsp.Body, rsp.Request.URL.Redacted()
+
+	if rsp.Header.Get("Accept-Ranges") != "bytes" {
+		return
+	}
+
+	if etag := rsp.Header.Get("ETag"); len(etag) != 0 && !strings.HasPrefix(etag, "W/") {
+		m.validator = etag
+	} else {
+		m.validator = rsp.Header.Get("Last-Modified")
+	}
+}
+
+func (m *resumableBody) Read(p []byte) (n int, e error) {
+	n, e = m.body.Read(p)
+	m.offset += int64(n)
+
+	if e == nil || e == io.EOF || len(m.validator) == 0 {
+		return
+	}
+
+	// the transfer timeout is exceeded
+	if m.ctx.Err() != nil {
+		m.api.log.Warn().Str("url", m.redacted).Int64("offset", m.offset).Msg("Download has been interrupted because of the transfer timeout")
+		return
+	}
+
+	// attempts are counted from the last progress, so long downloads could be resumed many times
+	if m.offset != m.resumedAt {
+		m.attempts = 0
+	}
+
+	if m.attempts++; m.attempts > resumeAttempts {
+		return
+	}
+
+	m.api.log.Warn().Err(e).Str("url", m.redacted).Int64("offset", m.offset).Int("attempt", m.attempts).
+		Msg("Download has been interrupted. It will be resumed from the offset.")
+
+	m.body.Close()
+
+	var err = sleepContext(m.ctx, m.api.retry.getDelay(m.attempts, nil))
+	if err == nil {
+		err = m.resume()
+	}
+
+	if err != nil {
+		m.api.log.Error().Err(err).Str("url", m.redacted).Msg("Could not resume the download")
+		m.body = ioutil.NopCloser(strings.NewReader(""))
+		return n, e
+	}
+
+	return n, nil
+}
+
+func (m *resumableBody) resume() (e error) {
+	var rsp *http.Response
+	if rsp, e = m.request(); e != nil {
+		return
+	}
+
+	// the request from the zero offset has no Range header, the whole file is expected
+	if m.offset == 0 && rsp.StatusCode == http.StatusOK {
+		m.body, m.resumedAt = rsp.Body, m.offset
+		return
+	}
+
+	// 200 is returned if the validator does not match, the whole file is sent in this case
+	if rsp.StatusCode != http.StatusPartialContent {
+		rsp.Body.Close()
+		if rsp.StatusCode == http.StatusOK {
+			return errRsmChanged
+		}
+
+		m.api.log.Warn().Int("status", rsp.StatusCode).Msg("Abnormal API response! Check it immediately!")
+		return nxsErrRq404
+	}
+
+	if start, err := getContentRangeStart(rsp); err != nil || start != m.offset {
+		rsp.Body.Close()
+		return errRsmChanged
+	}
+
+	m.body, m.resumedAt = rsp.Body, m.offset
+	return
+}
+
+// getContentRangeStart returns the first byte position of the partial response
+func getContentRangeStart(rsp *http.Response) (start int64, e error) {
+	_, e = fmt.Sscanf(rsp.Header.Get("Content-Range"), "bytes %d-", &start)
+	return
+}
+
+func (m *resumableBody) Close() error {
+	defer m.cancel()
+	return m.body.Close()
+}
+
+// openStagedAsset opens the asset download for the staging file. Partial files of the continued directory
+// (--process-continue-directory) are continued from their size with the stored validator, the returned offset
+// is the size of the staged part. The file is truncated if it could not be continued.
+func (m *Cloner) openStagedAsset(asset *NexusAsset, file *os.File) (body io.ReadCloser, offset int64, e error) {
+	resumer, ok := m.src.(assetResumer)
+	if !m.continued || !ok {
+		if e = truncateStagedFile(file); e != nil {
+			return
+		}
+
+		body, e = m.src.OpenAsset(asset)
+		return
+	}
+
+	var info os.FileInfo
+	if info, e = file.Stat(); e != nil {
+		return
+	}
+
+	validator, _ := ioutil.ReadFile(file.Name() + stagingValidatorExt)
+	if offset = info.Size(); len(validator) == 0 || asset.FileSize > 0 && offset > asset.FileSize {
+		offset = 0
+	}
+
+	// the staged file is complete, it's verified by the checksum only
+	if asset.FileSize > 0 && offset == asset.FileSize {
+		m.log.Info().Str("filename", asset.getHumanReadbleName()).Msg("Staging file of the interrupted download is complete")
+		return ioutil.NopCloser(strings.NewReader("")), offset, nil
+	}
+
+	var download *resumableBody
+	if download, e = resumer.ResumeAsset(asset, offset, string(validator)); e != nil && offset != 0 {
+		m.log.Warn().Err(e).Str("filename", asset.getHumanReadbleName()).Int64("offset", offset).
+			Msg("Could not continue the staging file. It will be downloaded again.")
+		download, e = resumer.ResumeAsset(asset, 0, "")
+	}
+
+	if e != nil {
+		return nil, 0, e
+	}
+
+	if offset = download.offset; offset != 0 {
+		m.log.Info().Str("filename", asset.getHumanReadbleName()).Int64("offset", offset).Msg("Staging file of the interrupted download is continued")
+	}
+
+	// the file is restarted from the beginning if it has been changed on the server
+	if _, e = file.Seek(offset, io.SeekStart); e == nil {
+		if e = file.Truncate(offset); e == nil {
+			e = ioutil.WriteFile(file.Name()+stagingValidatorExt, []byte(download.validator), 0600)
+		}
+	}
+
+	if e != nil {
+		download.Close()
+		return nil, 0, e
+	}
+
+	return download, offset, nil
+}
+
+// truncateStagedFile truncates the staging file for the download from the beginning, its validator is removed
+func truncateStagedFile(file *os.File) (e error) {
+	if _, e = file.Seek(0, io.SeekStart); e != nil {
+		return
+	}
+
+	if e = file.Truncate(0); e != nil {
+		return
+	}
+
+	if e = os.Remove(file.Name() + stagingValidatorExt); errors.Is(e, os.ErrNotExist) {
+		e = nil
+	}
+
+	return
+}
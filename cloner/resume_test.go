@@ -0,0 +1,251 @@
+package cloner
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestFileServer serves the file with Range support. The connection is dropped after the given
+// count of body bytes for every response, until drops responses have been interrupted.
+func newTestFileServer(t *testing.T, data []byte, chunk, drops int) *httptest.Server {
+	var mu sync.Mutex
+	var modified = time.Date(2021, 10, 20, 10, 10, 10, 0, time.UTC)
+
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		var drop = drops > 0
+		drops--
+		var content = data
+		mu.Unlock()
+
+		if !drop {
+			http.ServeContent(w, r, "file", modified, bytes.NewReader(content))
+			return
+		}
+
+		var start int
+		if rng := r.Header.Get("Range"); len(rng) != 0 {
+			if r.Header.Get("If-Range") != modified.Format(http.TimeFormat) {
+				http.ServeContent(w, r, "file", modified.Add(time.Hour), bytes.NewReader(content))
+				return
+			}
+
+			fmt.Sscanf(rng, "bytes=%d-", &start)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+		}
+
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)-start))
+		if start != 0 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+
+		var end = start + chunk
+		if end > len(content) {
+			end = len(content)
+		}
+
+		w.Write(content[start:end])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResumableBody(t *testing.T) {
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+
+	var tests = []struct {
+		name         string
+		chunk, drops int
+		failed       bool
+	}{
+		{"not interrupted", 0, 0, false},
+		{"resumed with the default retry policy", len(data) / 8, 7, false},
+		{"resumed many times with progress", 1024, 2 * len(data) / 1024, false},
+		{"resumed without progress", 0, resumeAttempts, false},
+		{"attempts without progress are exceeded", 0, resumeAttempts + 2, true},
+	}
+
+	for _, tt := range tests {
+		var server = newTestFileServer(t, data, tt.chunk, tt.drops)
+
+		body, e := newNexusApi(newTestSession(&Options{}), "src").getNexusFile(server.URL+"/file", int64(len(data)))
+		if e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		buf, e := ioutil.ReadAll(body)
+		body.Close()
+
+		if tt.failed {
+			if e == nil {
+				t.Errorf("%s: download must be failed", tt.name)
+			}
+			continue
+		}
+
+		if e != nil || !bytes.Equal(buf, data) {
+			t.Errorf("%s: got %d bytes and error %v, want %d bytes", tt.name, len(buf), e, len(data))
+		}
+	}
+}
+
+func TestResumableBodyChangedFile(t *testing.T) {
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var server = newTestFileServer(t, data, 1024, 1)
+
+	body, e := newNexusApi(newTestSession(&Options{}), "src").getNexusFile(server.URL+"/file", int64(len(data)))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer body.Close()
+
+	// the validator of the first response is replaced, so the file looks changed for If-Range
+	body.(*resumableBody).validator = "Thu, 21 Oct 2021 10:10:10 GMT"
+
+	buf, e := ioutil.ReadAll(body)
+	if e == nil || len(buf) != 1024 {
+		t.Errorf("got %d bytes and error %v, want the interrupted download", len(buf), e)
+	}
+}
+
+func TestGetNexusFileFrom(t *testing.T) {
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var modified = time.Date(2021, 10, 20, 10, 10, 10, 0, time.UTC).Format(http.TimeFormat)
+	var server = newTestFileServer(t, data, 0, 0)
+
+	var tests = []struct {
+		name      string
+		offset    int64
+		validator string
+		start     int64
+	}{
+		{"continued", 1000, modified, 1000},
+		{"changed file", 1000, "Thu, 21 Oct 2021 10:10:10 GMT", 0},
+		{"from the beginning", 0, "", 0},
+	}
+
+	for _, tt := range tests {
+		body, e := newNexusApi(newTestSession(&Options{}), "src").getNexusFileFrom(server.URL+"/file", int64(len(data)), tt.offset, tt.validator)
+		if e != nil {
+			t.Errorf("%s: %v", tt.name, e)
+			continue
+		}
+
+		buf, e := ioutil.ReadAll(body)
+		body.Close()
+
+		if body.offset != int64(len(data)) || body.validator != modified {
+			t.Errorf("%s: got offset %d and validator %q", tt.name, body.offset, body.validator)
+		}
+
+		if e != nil || !bytes.Equal(buf, data[tt.start:]) {
+			t.Errorf("%s: got %d bytes and error %v, want %d bytes", tt.name, len(buf), e, len(data)-int(tt.start))
+		}
+	}
+}
+
+func TestContinuedStagingFile(t *testing.T) {
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var modified = time.Date(2021, 10, 20, 10, 10, 10, 0, time.UTC).Format(http.TimeFormat)
+
+	var mu sync.Mutex
+	var ranges []string
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+
+		http.ServeContent(w, r, "file", time.Date(2021, 10, 20, 10, 10, 10, 0, time.UTC), bytes.NewReader(data))
+	}))
+	t.Cleanup(server.Close)
+
+	var tests = []struct {
+		name      string
+		staged    []byte
+		validator string
+		ranges    []string // Range headers of the requests
+		failed    bool
+	}{
+		{"continued", data[:1000], modified, []string{"bytes=1000-"}, false},
+		{"changed file", data[:1000], "Thu, 21 Oct 2021 10:10:10 GMT", []string{"bytes=1000-"}, false},
+		{"without validator", data[:1000], "", []string{""}, false},
+		{"complete", data, modified, nil, false},
+		{"longer than the asset", append(data, data[:10]...), modified, []string{""}, false},
+		{"corrupted", []byte("corrupted"), modified, []string{"bytes=9-"}, true},
+	}
+
+	for _, tt := range tests {
+		var dir = t.TempDir()
+		src, e := newMavenMirror(newTestSession(&Options{})).initiate("maven+" + server.URL + "/maven2")
+		if e != nil {
+			t.Fatal(e)
+		}
+
+		var cloner = New(&Options{ProcessContinueDirectory: dir}).WithEndpoints(src, nil)
+		if e = cloner.createTemporaryDirectory(); e != nil {
+			t.Fatal(e)
+		}
+
+		var asset = &NexusAsset{
+			Path:        "org/example/lib/1.0/lib-1.0.jar",
+			DownloadURL: server.URL + "/maven2/org/example/lib/1.0/lib-1.0.jar",
+			FileSize:    int64(len(data)),
+			Checksum:    &NexusAssetChecksum{Sha1: fmt.Sprintf("%x", sha1.Sum(data))},
+		}
+
+		var fpath = filepath.Join(dir, filepath.FromSlash(asset.Path))
+		writeTestFile(t, fpath, string(tt.staged), time.Now())
+		if len(tt.validator) != 0 {
+			writeTestFile(t, fpath+stagingValidatorExt, tt.validator, time.Now())
+		}
+
+		mu.Lock()
+		ranges = nil
+		mu.Unlock()
+
+		e = cloner.downloadAsset(asset)
+		cloner.destruct()
+
+		mu.Lock()
+		if fmt.Sprint(ranges) != fmt.Sprint(tt.ranges) {
+			t.Errorf("%s: range requests: got %q, want %q", tt.name, ranges, tt.ranges)
+		}
+		mu.Unlock()
+
+		staged, err := ioutil.ReadFile(fpath)
+		if err != nil {
+			t.Errorf("%s: continued directory has been removed: %v", tt.name, err)
+			continue
+		}
+
+		if tt.failed {
+			validator, _ := ioutil.ReadFile(fpath + stagingValidatorExt)
+			if e != errClChecksum || len(staged) != 0 || len(validator) != 0 {
+				t.Errorf("%s: got error %v and %d staged bytes, want the truncated file without validator", tt.name, e, len(staged))
+			}
+			continue
+		}
+
+		if e != nil || !bytes.Equal(staged, data) {
+			t.Errorf("%s: got %d staged bytes and error %v, want %d bytes", tt.name, len(staged), e, len(data))
+		}
+
+		if validator, _ := ioutil.ReadFile(fpath + stagingValidatorExt); string(validator) != modified {
+			t.Errorf("%s: validator: got %q, want %q", tt.name, validator, modified)
+		}
+	}
+}
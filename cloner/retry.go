@@ -0,0 +1,173 @@
+package cloner
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryPolicy describes retries of the failed api requests
+type retryPolicy struct {
+	attempts           int
+	minDelay, maxDelay time.Duration
+}
+
+func newRetryPolicy(opts *Options) *retryPolicy {
+	var policy = &retryPolicy{
+		attempts: opts.HTTPRetryAttempts,
+		minDelay: opts.HTTPRetryDelay,
+		maxDelay: opts.HTTPRetryMaxDelay,
+	}
+
+	if policy.attempts < 1 {
+		policy.attempts = 1
+	}
+
+	return policy
+}
+
+// isIdempotentMethod reports that the request could be repeated without side effects
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+
+	return false
+}
+
+// isRetryableStatus reports that the response status is transient (overloaded or restarting server, gateway errors)
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// getDelay returns exponential backoff with jitter for the given attempt (from 1).
+// Retry-After header of 429 and 503 responses is used if it's longer.
+func (m *retryPolicy) getDelay(attempt int, rsp *http.Response) time.Duration {
+	var delay = m.minDelay
+	for i := 1; i < attempt && delay < m.maxDelay; i++ {
+		delay = delay * 2
+	}
+
+	if delay > m.maxDelay {
+		delay = m.maxDelay
+	}
+
+	if delay > 0 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	if rsp == nil || (rsp.StatusCode != http.StatusTooManyRequests && rsp.StatusCode != http.StatusServiceUnavailable) {
+		return delay
+	}
+
+	if after := getRetryAfter(rsp.Header.Get("Retry-After")); after > delay {
+		return after
+	}
+
+	return delay
+}
+
+// getRetryAfter parses Retry-After header in seconds or http-date format
+func getRetryAfter(header string) time.Duration {
+	if len(header) == 0 {
+		return 0
+	}
+
+	if seconds, e := strconv.Atoi(header); e == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, e := http.ParseTime(header); e == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// sleepContext waits for the delay or the context cancellation
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	var timer = time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doNexusRequest sends the request and retries it on transport errors and transient statuses.
+// Every attempt is limited by the given timeout (0 is unlimited), it's cancelled on the response body closing.
+// Requests with not idempotent methods are retried only if isCompleted is given. It's called
+// before every retry and stops retries if the previous attempt has been applied by the server.
+// The response of the last attempt is returned as is.
+func (m *nexusApi) doNexusRequest(req *http.Request, timeout time.Duration, isCompleted func() bool) (rsp *http.Response, e error) {
+	var attempts = m.retry.attempts
+	if !isIdempotentMethod(req.Method) && isCompleted == nil {
+		attempts = 1
+	}
+
+	// streamed bodies could not be sent twice
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt != 1 && req.GetBody != nil {
+			if req.Body, e = req.GetBody(); e != nil {
+				return
+			}
+		}
+
+		var ctx, cancel = newRequestContext(req.Context(), timeout)
+
+		if rsp, e = m.Client.Do(req.WithContext(ctx)); e == nil && (!isRetryableStatus(rsp.StatusCode) || attempt >= attempts) {
+			rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: cancel}
+			return
+		}
+
+		if attempt >= attempts {
+			cancel()
+			return
+		}
+
+		// the transfer context could be cancelled
+		if req.Context().Err() != nil {
+			if rsp != nil {
+				rsp.Body.Close()
+			}
+			cancel()
+			return nil, req.Context().Err()
+		}
+
+		var delay = m.retry.getDelay(attempt, rsp)
+		var event = m.log.Warn().Err(e).Str("method", req.Method).Str("url", req.URL.Redacted()).
+			Int("attempt", attempt).Dur("delay", delay)
+
+		if rsp != nil {
+			event = event.Int("status", rsp.StatusCode)
+			rsp.Body.Close()
+		}
+		cancel()
+		event.Msg("Request has been failed. It will be retried after the delay.")
+
+		if e = sleepContext(req.Context(), delay); e != nil {
+			return nil, e
+		}
+
+		if isCompleted != nil && isCompleted() {
+			m.log.Info().Str("url", req.URL.Redacted()).Msg("The previous request attempt has been applied by the server. Retries are stopped.")
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody}, nil
+		}
+	}
+}
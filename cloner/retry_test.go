@@ -0,0 +1,198 @@
+package cloner
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetRetryAfter(t *testing.T) {
+	var tests = []struct {
+		name     string
+		header   string
+		min, max time.Duration
+	}{
+		{"empty", "", 0, 0},
+		{"seconds", "120", 2 * time.Minute, 2 * time.Minute},
+		{"zero seconds", "0", 0, 0},
+		{"negative seconds", "-5", 0, 0},
+		{"invalid", "soon", 0, 0},
+		{"http date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if delay := getRetryAfter(tt.header); delay < tt.min || delay > tt.max {
+			t.Errorf("%s: got %v, want from %v to %v", tt.name, delay, tt.min, tt.max)
+		}
+	}
+
+	// the past date gives no delay over the backoff
+	if delay := getRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)); delay > 0 {
+		t.Errorf("past http date: got %v, want no delay", delay)
+	}
+}
+
+func TestRetryPolicyGetDelay(t *testing.T) {
+	var policy = newRetryPolicy(&Options{HTTPRetryAttempts: 10, HTTPRetryDelay: time.Second, HTTPRetryMaxDelay: 4 * time.Second})
+
+	var newResponse = func(status int, retryAfter string) *http.Response {
+		var rsp = &http.Response{StatusCode: status, Header: make(http.Header)}
+		rsp.Header.Set("Retry-After", retryAfter)
+		return rsp
+	}
+
+	var tests = []struct {
+		name     string
+		attempt  int
+		rsp      *http.Response
+		min, max time.Duration
+	}{
+		{"first attempt", 1, nil, 500 * time.Millisecond, time.Second},
+		{"second attempt", 2, nil, time.Second, 2 * time.Second},
+		{"capped attempt", 3, nil, 2 * time.Second, 4 * time.Second},
+		{"capped far attempt", 100, nil, 2 * time.Second, 4 * time.Second},
+		{"longer retry after", 1, newResponse(http.StatusServiceUnavailable, "30"), 30 * time.Second, 30 * time.Second},
+		{"longer retry after of too many requests", 1, newResponse(http.StatusTooManyRequests, "30"), 30 * time.Second, 30 * time.Second},
+		{"shorter retry after", 3, newResponse(http.StatusServiceUnavailable, "1"), 2 * time.Second, 4 * time.Second},
+		{"retry after of bad gateway", 1, newResponse(http.StatusBadGateway, "30"), 500 * time.Millisecond, time.Second},
+	}
+
+	for _, tt := range tests {
+		// the jitter is random, so every delay is checked several times
+		for i := 0; i < 100; i++ {
+			if delay := policy.getDelay(tt.attempt, tt.rsp); delay < tt.min || delay > tt.max {
+				t.Errorf("%s: got %v, want from %v to %v", tt.name, delay, tt.min, tt.max)
+				break
+			}
+		}
+	}
+
+	if delay := newRetryPolicy(&Options{}).getDelay(5, nil); delay != 0 {
+		t.Errorf("disabled delay: got %v, want 0", delay)
+	}
+}
+
+// testRetryServer responds with the given statuses to the sequential requests (200 after them).
+// HEAD requests find the file after the first POST request.
+type testRetryServer struct {
+	*httptest.Server
+	sync.Mutex
+
+	statuses []int
+	requests []string
+	posted   bool
+}
+
+func newTestRetryServer(t *testing.T, statuses ...int) *testRetryServer {
+	var server = &testRetryServer{statuses: statuses}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+
+		server.Lock()
+		defer server.Unlock()
+
+		server.requests = append(server.requests, r.Method)
+
+		if r.Method == "HEAD" {
+			if !server.posted {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+
+		server.posted = server.posted || r.Method == "POST"
+
+		if len(server.statuses) != 0 {
+			var status = server.statuses[0]
+			server.statuses = server.statuses[1:]
+
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+		}
+
+		w.Write([]byte("{}"))
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDoNexusRequest(t *testing.T) {
+	var tests = []struct {
+		name     string
+		statuses []int
+		request  func(api *nexusApi, url string) error
+		requests []string
+		failed   bool
+	}{
+		{"get is retried", []int{http.StatusBadGateway, http.StatusOK},
+			func(api *nexusApi, url string) error {
+				_, e := api.getNexusRawRequest(url)
+				return e
+			},
+			[]string{"GET", "GET"}, false},
+		{"get attempts are exceeded", []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+			func(api *nexusApi, url string) error {
+				_, e := api.getNexusRawRequest(url)
+				return e
+			},
+			[]string{"GET", "GET", "GET"}, true},
+		{"not found get is not retried", []int{http.StatusNotFound},
+			func(api *nexusApi, url string) error {
+				_, e := api.getNexusRawRequest(url)
+				return e
+			},
+			[]string{"GET"}, true},
+		{"applied post is not sent twice", []int{http.StatusServiceUnavailable},
+			func(api *nexusApi, url string) error {
+				return api.putNexusFile(url, bytes.NewReader([]byte("data")), 4, "text/plain", func() bool {
+					found, _, _ := api.headNexusFile(url)
+					return found
+				})
+			},
+			[]string{"POST", "HEAD"}, false},
+		{"not applied post is retried", []int{http.StatusBadGateway, http.StatusNoContent},
+			func(api *nexusApi, url string) error {
+				return api.putNexusFile(url, bytes.NewReader([]byte("data")), 4, "text/plain", func() bool { return false })
+			},
+			[]string{"POST", "POST"}, false},
+		{"post without check is not retried", []int{http.StatusServiceUnavailable},
+			func(api *nexusApi, url string) error {
+				return api.sendNexusRequest("POST", url, map[string]string{"name": "test"})
+			},
+			[]string{"POST"}, true},
+		{"streamed body is not retried", []int{http.StatusServiceUnavailable},
+			func(api *nexusApi, url string) error {
+				return api.putNexusFile(url, io.MultiReader(strings.NewReader("data")), 4, "text/plain", func() bool { return false })
+			},
+			[]string{"POST"}, true},
+	}
+
+	for _, tt := range tests {
+		var server = newTestRetryServer(t, tt.statuses...)
+		var api = newNexusApi(newTestSession(&Options{
+			HTTPRetryAttempts: 3,
+			HTTPRetryDelay:    time.Millisecond,
+			HTTPRetryMaxDelay: 10 * time.Millisecond,
+		}), "dst")
+
+		if e := tt.request(api, server.URL+"/file"); (e != nil) != tt.failed {
+			t.Errorf("%s: got error %v, want failed %v", tt.name, e, tt.failed)
+		}
+
+		server.Lock()
+		if strings.Join(server.requests, ",") != strings.Join(tt.requests, ",") {
+			t.Errorf("%s: requests: got %q, want %q", tt.name, server.requests, tt.requests)
+		}
+		server.Unlock()
+	}
+}
@@ -0,0 +1,108 @@
+package cloner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+)
+
+var (
+	errSecInvPasswords = errors.New("There is invalid value in --user-passwords option. Option supports skip and generate values.")
+	errSecNoPassFile   = errors.New("Generated user passwords require --user-passwords-file option.")
+)
+
+var nexusSecurityKinds = []*nexusConfigKind{
+	{
+		name:     "privileges",
+		listPath: "/service/rest/v1/security/privileges",
+		key:      "name",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/security/privileges/" + url.PathEscape(item.getString("type")) + "/" + url.PathEscape(item.getString("name"))
+		},
+		createPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/security/privileges/" + url.PathEscape(item.getString("type"))
+		},
+		skipReadOnly: true,
+		readOnly:     []string{"type", "readOnly"},
+	},
+	{
+		name:     "roles",
+		listPath: "/service/rest/v1/security/roles?source=default",
+		key:      "id",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/security/roles/" + url.PathEscape(item.getString("id"))
+		},
+		createPath:   func(nexusConfigItem) string { return "/service/rest/v1/security/roles" },
+		skipReadOnly: true,
+		readOnly:     []string{"source", "readOnly"},
+	},
+	{
+		name:     "users",
+		listPath: "/service/rest/v1/security/users?source=default",
+		key:      "userId",
+		itemPath: func(item nexusConfigItem) string {
+			return "/service/rest/v1/security/users/" + url.PathEscape(item.getString("userId"))
+		},
+		createPath:   func(nexusConfigItem) string { return "/service/rest/v1/security/users" },
+		skipReadOnly: true,
+		withPassword: true,
+		readOnly:     []string{"readOnly", "externalRoles"},
+	},
+}
+
+// CloneSecurity clones the security configuration (security-clone command). Source and Destination
+// options are Nexus server urls.
+func (m *Cloner) CloneSecurity(ctx context.Context, cfg *ConfigOptions) (e error) {
+	var cancel = m.initContext(ctx)
+	defer cancel()
+
+	var kinds []*nexusConfigKind
+	if kinds, e = m.getConfigKinds(nexusSecurityKinds, cfg.Items); e != nil {
+		return
+	}
+
+	var opts = &configCloneOptions{
+		dryRun:    cfg.DryRun,
+		update:    cfg.UpdateExisting,
+		passwords: cfg.UserPasswords,
+	}
+
+	switch opts.passwords {
+	case "skip":
+	case "generate":
+		if len(cfg.UserPasswordsFile) == 0 {
+			return errSecNoPassFile
+		}
+
+		if !opts.dryRun {
+			var file *os.File
+			if file, e = os.OpenFile(cfg.UserPasswordsFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600); e != nil {
+				return
+			}
+			defer file.Close()
+
+			opts.passwordsFile = file
+		}
+	default:
+		return errSecInvPasswords
+	}
+
+	var src, dst *nexus
+	if src, dst, e = initiateServers(m.session, m.opts.Source, m.opts.Destination); e != nil {
+		return
+	}
+
+	return m.cloneConfig(src, dst, kinds, opts)
+}
+
+func getRandomPassword() (string, error) {
+	var buf = make([]byte, 18)
+	if _, e := rand.Read(buf); e != nil {
+		return "", e
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
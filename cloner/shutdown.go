@@ -0,0 +1,101 @@
+package cloner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errClInterrupted = errors.New("Process has been interrupted by the shutdown request.")
+)
+
+// syncState is the progress state which is written to --state-file on the interruption
+type syncState struct {
+	Time         time.Time           `json:"time"`
+	Repositories []*RepositoryReport `json:"repositories"`
+}
+
+// initContext creates the session context for the requests, it's cancelled after --shutdown-timeout
+// since ctx is done. Returned function must be called on the exit for the watcher goroutine stopping.
+func (m *Cloner) initContext(ctx context.Context) context.CancelFunc {
+	var cancel context.CancelFunc
+	m.stop = ctx
+	m.session.ctx, cancel = newAbortContext(ctx, m.log, m.opts.ShutdownTimeout)
+	return cancel
+}
+
+// newAbortContext returns the context which is cancelled after the timeout since the given context is done
+func newAbortContext(ctx context.Context, log *zerolog.Logger, timeout time.Duration) (context.Context, context.CancelFunc) {
+	abort, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-abort.Done():
+			return
+		}
+
+		log.Warn().Dur("timeout", timeout).Msg("Shutdown has been requested. New transfers will not be started, in-flight transfers will be cancelled after the timeout.")
+
+		var timer = time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			log.Warn().Msg("Shutdown timeout has been reached. In-flight transfers are cancelled.")
+			cancel()
+		case <-abort.Done():
+		}
+	}()
+
+	return abort, cancel
+}
+
+// isInterrupted returns true if the synchronization must not start new transfers
+func (m *Cloner) isInterrupted() bool {
+	return m.stop != nil && m.stop.Err() != nil
+}
+
+// complete removes the transferred asset from the pending list of the report
+func (m *RepositoryReport) complete(asset *NexusAsset) {
+	delete(m.pending, asset.Path)
+}
+
+// finish prints reports of the given cloners. If the synchronization has been interrupted,
+// the progress state is written to --state-file and errClInterrupted is returned.
+func (m *Cloner) finish(cloners []*Cloner) (report *Report, e error) {
+	report = m.getReport(cloners)
+	e = m.printReports(report)
+
+	if !report.Interrupted {
+		return
+	}
+
+	if len(m.opts.StateFile) != 0 {
+		if err := m.writeState(report); err != nil {
+			m.log.Error().Err(err).Str("filename", m.opts.StateFile).Msg("Could not write the progress state")
+		}
+	}
+
+	return report, errClInterrupted
+}
+
+func (m *Cloner) writeState(report *Report) (e error) {
+	var buf []byte
+	if buf, e = json.MarshalIndent(&syncState{Time: time.Now(), Repositories: report.Repositories}, "", "  "); e != nil {
+		return
+	}
+
+	if e = ioutil.WriteFile(m.opts.StateFile, buf, 0600); e != nil {
+		return
+	}
+
+	m.log.Info().Str("filename", m.opts.StateFile).Int("repositories", len(report.Repositories)).
+		Msg("Progress state has been written")
+	return
+}
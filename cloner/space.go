@@ -0,0 +1,105 @@
+package cloner
+
+import (
+	"errors"
+)
+
+var (
+	errSpcUnsupported = errors.New("Free disk space could not be checked on this platform.")
+	errSpcNoFree      = errors.New("There is no enough free space in the temporary directory for missing assets. Free some space, use --max-staging-bytes or --stream options.")
+	errSpcUnknownSize = errors.New("Could not get the asset size from the server.")
+)
+
+// assetSizer is the source which could get sizes of assets without the size in the listing
+type assetSizer interface {
+	StatAsset(*NexusAsset) (int64, error)
+}
+
+func getRemoteFileSize(api *nexusApi, url string) (int64, error) {
+	found, size, e := api.headNexusFile(url)
+	if e != nil {
+		return 0, e
+	}
+
+	if !found {
+		return 0, nxsErrRq404
+	}
+
+	if size < 0 {
+		return 0, errSpcUnknownSize
+	}
+
+	return size, nil
+}
+
+// getAssetsSizes fills unknown asset sizes with HEAD requests and returns the total size.
+// Assets, which size could not be got, are counted as empty.
+func (m *Cloner) getAssetsSizes(assets []*NexusAsset) (total int64) {
+	sizer, ok := m.src.(assetSizer)
+
+	for _, asset := range assets {
+		if asset.FileSize == 0 && ok {
+			size, e := sizer.StatAsset(asset)
+			if e != nil {
+				m.log.Warn().Err(e).Str("path", asset.Path).Msg("Could not get the asset size. It will not be counted in the free space check.")
+			}
+
+			asset.FileSize = size
+		}
+
+		total += asset.FileSize
+	}
+
+	return
+}
+
+// getStagingBatches checks free space of the temporary directory and splits assets into batches
+// which are not bigger than --max-staging-bytes. Every batch is downloaded, uploaded and removed
+// from the temporary directory before the next one.
+func (m *Cloner) getStagingBatches(assets []*NexusAsset) (batches [][]*NexusAsset, e error) {
+	var total = m.getAssetsSizes(assets)
+	var limit = m.opts.MaxStagingBytes
+
+	var required int64
+	if limit <= 0 {
+		batches, required = [][]*NexusAsset{assets}, total
+	} else {
+		var batch []*NexusAsset
+		var size int64
+
+		for _, asset := range assets {
+			if len(batch) != 0 && size+asset.FileSize > limit {
+				batches, batch, size = append(batches, batch), nil, 0
+			}
+
+			if asset.FileSize > limit {
+				m.log.Warn().Str("path", asset.Path).Int64("size", asset.FileSize).Msg("The asset is bigger than --max-staging-bytes. It will be staged alone.")
+			}
+
+			batch, size = append(batch, asset), size+asset.FileSize
+			if size > required {
+				required = size
+			}
+		}
+
+		if len(batch) != 0 {
+			batches = append(batches, batch)
+		}
+	}
+
+	var free uint64
+	if free, e = getFreeSpace(m.tempPath); e != nil {
+		m.log.Warn().Err(e).Str("path", m.tempPath).Msg("Could not check free space of the temporary directory. The check will be skipped.")
+		return batches, nil
+	}
+
+	m.log.Info().Int64("total", total).Int64("required", required).Uint64("free", free).Int("batches", len(batches)).
+		Msg("Free space of the temporary directory has been checked")
+
+	if uint64(required) > free {
+		m.log.Error().Int64("required", required).Uint64("free", free).Str("path", m.tempPath).Msg("There is no enough free space for missing assets")
+		return nil, errSpcNoFree
+	}
+
+	return
+}
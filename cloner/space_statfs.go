@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package cloner
+
+import "syscall"
+
+// getFreeSpace returns bytes which are available for unprivileged users
+func getFreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if e := syscall.Statfs(path, &stat); e != nil {
+		return 0, e
+	}
+
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,26 @@
+//go:build windows
+// +build windows
+
+package cloner
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// getFreeSpace returns bytes which are available for the current user (quotas are respected)
+func getFreeSpace(path string) (uint64, error) {
+	buf, e := syscall.UTF16PtrFromString(path)
+	if e != nil {
+		return 0, e
+	}
+
+	var free uint64
+	if ok, _, e := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(buf)), uintptr(unsafe.Pointer(&free)), 0, 0); ok == 0 {
+		return 0, e
+	}
+
+	return free, nil
+}
@@ -0,0 +1,117 @@
+package cloner
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	errStInvPath = errors.New("The asset path is invalid. It's absolute or it's pointing outside of the repository.")
+
+	// windows drive letters are rejected on all systems, so the same assets are staged everywhere
+	storageDriveRegexp = regexp.MustCompile(`^[A-Za-z]:`)
+)
+
+// tempStorage keeps the temporary directory which is used for assets staging
+// between the download and upload stages.
+// Partial files of the continued directory are downloaded from their size.
+type tempStorage struct {
+	tempPath   string
+	repository string // subdirectory of the continued directory for the concurrent repositories
+	continued  bool   // tempPath is --process-continue-directory, it's kept after the synchronization
+
+	*session
+}
+
+func (m *tempStorage) destruct() {
+	if len(m.tempPath) != 0 && !m.opts.TempPathSave && !m.continued {
+		if e := os.RemoveAll(m.tempPath); e != nil {
+			m.log.Warn().Err(e).Msg("There is some errors in Destruct() function. Looks bad.")
+		}
+	}
+}
+
+func (m *tempStorage) createTemporaryDirectory() (e error) {
+	if len(m.opts.ProcessContinueDirectory) != 0 {
+		return m.createContinuedDirectory()
+	}
+
+	pathPrefix := m.opts.TempPathPrefix
+	if runtime.GOOS == "linux" && len(pathPrefix) == 0 {
+		pathPrefix = "/var/tmp"
+	}
+
+	m.log.Debug().Msgf("creating temporary path with %s prefix", pathPrefix)
+	if m.tempPath, e = ioutil.TempDir(pathPrefix, "*"); e != nil {
+		return
+	}
+
+	return
+}
+
+// createContinuedDirectory uses --process-continue-directory as the temporary directory, so files of the interrupted
+// run are continued. Repositories of --all, --repo-regex and group members modes are staged in their subdirectories.
+func (m *tempStorage) createContinuedDirectory() (e error) {
+	var tempPath = m.opts.ProcessContinueDirectory
+	if len(m.repository) != 0 {
+		if tempPath, e = joinRelativePath(tempPath, m.repository); e != nil {
+			return
+		}
+	}
+
+	m.log.Debug().Str("path", tempPath).Msg("using the continued temporary path")
+	if e = os.MkdirAll(tempPath, 0700); e != nil {
+		return
+	}
+
+	m.tempPath, m.continued = tempPath, true
+	return
+}
+
+// removeStagedAssets removes staged files of the given assets from the temporary directory
+func (m *tempStorage) removeStagedAssets(assets []*NexusAsset) {
+	for _, asset := range assets {
+		fpath, e := joinRelativePath(m.tempPath, asset.Path)
+		if e != nil {
+			continue
+		}
+
+		for _, fpath := range []string{fpath, fpath + stagingValidatorExt} {
+			if e = os.Remove(fpath); e != nil && !errors.Is(e, os.ErrNotExist) {
+				m.log.Warn().Err(e).Str("filename", fpath).Msg("Could not remove the staged file")
+			}
+		}
+	}
+}
+
+// joinRelativePath joins the slash separated asset path to the root directory. Asset paths are given
+// by remote servers, so absolute paths and paths which are leaving the root (../) are rejected.
+func joinRelativePath(root, p string) (_ string, e error) {
+	if len(p) == 0 || strings.ContainsAny(p, "\\\x00") || path.IsAbs(p) || storageDriveRegexp.MatchString(p) {
+		return "", errStInvPath
+	}
+
+	var clean = path.Clean(p)
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") || len(filepath.VolumeName(filepath.FromSlash(clean))) != 0 {
+		return "", errStInvPath
+	}
+
+	var fpath = filepath.Join(root, filepath.FromSlash(clean))
+
+	var rel string
+	if rel, e = filepath.Rel(root, fpath); e != nil {
+		return
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errStInvPath
+	}
+
+	return fpath, nil
+}
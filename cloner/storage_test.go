@@ -0,0 +1,41 @@
+package cloner
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinRelativePath(t *testing.T) {
+	var root = filepath.Join("tmp", "staging")
+
+	var tests = []struct {
+		path, fpath string
+		e           error
+	}{
+		{"org/example/lib/1.0/lib-1.0.jar", filepath.Join(root, "org", "example", "lib", "1.0", "lib-1.0.jar"), nil},
+		{"a/./b//c", filepath.Join(root, "a", "b", "c"), nil},
+		{"a/../x", filepath.Join(root, "x"), nil},
+		{"a..b/..c", filepath.Join(root, "a..b", "..c"), nil},
+		{"ab:c", filepath.Join(root, "ab:c"), nil},
+		{"", "", errStInvPath},
+		{".", "", errStInvPath},
+		{"a/..", "", errStInvPath},
+		{"..", "", errStInvPath},
+		{"../x", "", errStInvPath},
+		{"a/../../x", "", errStInvPath},
+		{"/abs", "", errStInvPath},
+		{"//host/share/x", "", errStInvPath},
+		{"a\\b", "", errStInvPath},
+		{"..\\x", "", errStInvPath},
+		{"a\x00b", "", errStInvPath},
+		{"C:/x", "", errStInvPath},
+		{"c:x", "", errStInvPath},
+	}
+
+	for _, tt := range tests {
+		fpath, e := joinRelativePath(root, tt.path)
+		if fpath != tt.fpath || e != tt.e {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", tt.path, fpath, e, tt.fpath, tt.e)
+		}
+	}
+}
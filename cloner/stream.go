@@ -0,0 +1,114 @@
+package cloner
+
+import (
+	"encoding/hex"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// assetStreamer is the destination which uploads assets directly from the source stream.
+// Destinations without it are streamed with WriteAsset.
+type assetStreamer interface {
+	StreamAsset(*NexusAsset, io.Reader) error
+}
+
+// checksumReader verifies the checksum of the read data. Mismatch error is returned instead of io.EOF,
+// so the upload is aborted before its completion.
+type checksumReader struct {
+	io.Reader
+
+	sum      hash.Hash
+	checksum string
+
+	log *zerolog.Logger
+}
+
+// newChecksumReader returns the reader which verifies the asset checksum (if it's known)
+func newChecksumReader(r io.Reader, asset *NexusAsset, log *zerolog.Logger) io.Reader {
+	var sum, checksum = asset.getChecksumHash()
+	if sum == nil {
+		return r
+	}
+
+	return &checksumReader{Reader: r, sum: sum, checksum: checksum, log: log}
+}
+
+func (m *checksumReader) Read(p []byte) (n int, e error) {
+	n, e = m.Reader.Read(p)
+	m.sum.Write(p[:n])
+
+	if e == io.EOF && !strings.EqualFold(hex.EncodeToString(m.sum.Sum(nil)), m.checksum) {
+		m.log.Error().Str("checksum", m.checksum).Msg("Transferred file checksum does not match the asset checksum")
+		return n, errClChecksum
+	}
+
+	return
+}
+
+// streamMissingAssets transfers assets from the source to the destination without temporary files.
+// Failed assets are returned for the disk staging.
+func (m *Cloner) streamMissingAssets(assets []*NexusAsset) (failed []*NexusAsset, e error) {
+	for i, asset := range assets {
+		if m.isInterrupted() {
+			return failed, errClInterrupted
+		}
+
+		if e := m.streamAsset(asset); e != nil {
+			m.log.Warn().Err(e).Msgf("There is error while streaming asset. Asset %s will be transferred with the disk staging.", asset.ID)
+			failed = append(failed, asset)
+			continue
+		}
+
+		m.report.Downloaded++
+		m.report.Uploaded++
+		m.report.complete(asset)
+		m.log.Info().Msgf("The asset %s has been streamed successfully. Remaining %d files", asset.getHumanReadbleName(), len(assets)-i-1)
+	}
+
+	return
+}
+
+// streamAsset streams the asset from the cache or from the source. Downloaded assets are added to the cache
+// after the successful upload (the checksum is verified at the end of the stream).
+func (m *Cloner) streamAsset(asset *NexusAsset) (e error) {
+	var body io.ReadCloser
+	var isCached bool
+	if m.cache != nil {
+		body, isCached = m.cache.open(asset)
+	}
+
+	if !isCached {
+		if body, e = m.src.OpenAsset(asset); e != nil {
+			return
+		}
+	}
+	defer body.Close()
+
+	var reader = newChecksumReader(body, asset, m.log)
+
+	var cached *cacheWriter
+	if m.cache != nil && !isCached {
+		if cached, _ = m.cache.create(asset); cached != nil {
+			reader = io.TeeReader(reader, cached)
+		}
+	}
+
+	if streamer, ok := m.dst.(assetStreamer); ok {
+		e = streamer.StreamAsset(asset, reader)
+	} else {
+		e = m.dst.WriteAsset(asset, reader)
+	}
+
+	if cached != nil {
+		cached.close(e)
+	}
+
+	if isCached && e == nil {
+		m.report.Cached++
+	}
+
+	return
+}
@@ -0,0 +1,112 @@
+package cloner
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// httpTimeouts are timeouts of the api client. Connect, TLS handshake and response header timeouts
+// are set in the transport, api and transfer timeouts are set with the request context.
+type httpTimeouts struct {
+	connect, tls, header time.Duration
+
+	api      time.Duration // whole api request with the response body
+	idle     time.Duration // max time without any received data of the file body
+	transfer time.Duration // base time of the file transfer, it's scaled by the file size with transferRate
+
+	transferRate int64 // min expected transfer speed (bytes per second)
+}
+
+// newHttpTimeouts returns timeouts of the given endpoint role (src or dst). The transfer timeout is scaled
+// with the endpoint bandwidth limit if it's lower than --http-transfer-min-rate, so throttled transfers
+// are not cancelled by the deadline.
+func newHttpTimeouts(opts *Options, role string) *httpTimeouts {
+	var timeouts = &httpTimeouts{
+		connect:      opts.HTTPConnectTimeout,
+		tls:          opts.HTTPTLSTimeout,
+		header:       opts.HTTPHeaderTimeout,
+		api:          opts.HTTPClientTimeout,
+		idle:         opts.HTTPIdleTimeout,
+		transfer:     opts.HTTPTransferTimeout,
+		transferRate: opts.HTTPTransferMinRate,
+	}
+
+	if rate := opts.getTransferBandwidth(role); rate > 0 && (timeouts.transferRate <= 0 || rate < timeouts.transferRate) {
+		timeouts.transferRate = rate
+	}
+
+	return timeouts
+}
+
+// getTransferTimeout returns the transfer timeout for the file with the given size (0 is unknown)
+func (m *httpTimeouts) getTransferTimeout(size int64) time.Duration {
+	if m.transfer == 0 {
+		return 0
+	}
+
+	if size > 0 && m.transferRate > 0 {
+		return m.transfer + time.Duration(size/m.transferRate)*time.Second
+	}
+
+	return m.transfer
+}
+
+// getTransferContext returns the context which is used for all requests of the file transfer (including resumes)
+func (m *httpTimeouts) getTransferContext(ctx context.Context, size int64) (context.Context, context.CancelFunc) {
+	return newRequestContext(ctx, m.getTransferTimeout(size))
+}
+
+// newRequestContext returns the context of the request attempt, it has the deadline if the timeout is set
+func newRequestContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout != 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// cancelBody cancels the request context on the body closing
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (m *cancelBody) Close() error {
+	defer m.cancel()
+	return m.ReadCloser.Close()
+}
+
+// idleBody cancels the request if there is no received data for the idle timeout
+type idleBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func newIdleBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) io.ReadCloser {
+	if timeout == 0 {
+		return &cancelBody{ReadCloser: body, cancel: cancel}
+	}
+
+	return &idleBody{
+		ReadCloser: body,
+		cancel:     cancel,
+		timer:      time.AfterFunc(timeout, cancel),
+		timeout:    timeout,
+	}
+}
+
+func (m *idleBody) Read(p []byte) (n int, e error) {
+	n, e = m.ReadCloser.Read(p)
+	m.timer.Reset(m.timeout)
+	return
+}
+
+func (m *idleBody) Close() error {
+	m.timer.Stop()
+	defer m.cancel()
+	return m.ReadCloser.Close()
+}
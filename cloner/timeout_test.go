@@ -0,0 +1,73 @@
+package cloner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetTransferTimeout(t *testing.T) {
+	var tests = []struct {
+		name    string
+		opts    Options
+		size    int64
+		timeout time.Duration
+	}{
+		{"disabled", Options{HTTPTransferMinRate: 1024}, 1 << 20, 0},
+		{"unknown size", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 1024}, 0, time.Minute},
+		{"min rate", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024}, 1 << 20, time.Minute + 16*time.Second},
+		{"no min rate", Options{HTTPTransferTimeout: time.Minute}, 1 << 20, time.Minute},
+		{"higher bandwidth", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 1 << 20}, 1 << 20,
+			time.Minute + 16*time.Second},
+		{"lower bandwidth", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 32 * 1024}, 512 * 1024,
+			time.Minute + 16*time.Second},
+		{"bandwidth without min rate", Options{HTTPTransferTimeout: time.Minute, MaxBandwidth: 32 * 1024}, 512 * 1024,
+			time.Minute + 16*time.Second},
+		{"endpoint bandwidth", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 16 * 1024, SrcMaxBandwidth: 1 << 20}, 1 << 20,
+			time.Minute + 16*time.Second},
+		{"single repository", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 128 * 1024, RepoConcurrency: 4}, 1 << 20,
+			time.Minute + 16*time.Second},
+		{"concurrent repositories", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 128 * 1024, RepoConcurrency: 4, All: true}, 1 << 20,
+			time.Minute + 32*time.Second},
+		{"concurrent group members", Options{HTTPTransferTimeout: time.Minute, HTTPTransferMinRate: 64 * 1024, MaxBandwidth: 128 * 1024, RepoConcurrency: 4, GroupMode: "members"}, 1 << 20,
+			time.Minute + 32*time.Second},
+	}
+
+	for _, tt := range tests {
+		if timeout := newHttpTimeouts(&tt.opts, "src").getTransferTimeout(tt.size); timeout != tt.timeout {
+			t.Errorf("%s: got %v, want %v", tt.name, timeout, tt.timeout)
+		}
+	}
+}
+
+func TestThrottledTransfer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("throttled transfer takes several seconds")
+	}
+
+	var data = bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	// the transfer takes 3s (the burst is free), min rate gives 1.1s for it
+	var api = newNexusApi(newTestSession(&Options{
+		HTTPTransferTimeout: 100 * time.Millisecond,
+		HTTPTransferMinRate: 64 * 1024,
+		MaxBandwidth:        16 * 1024,
+	}), "src")
+
+	body, e := api.getNexusFile(server.URL+"/file", int64(len(data)))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer body.Close()
+
+	if buf, e := ioutil.ReadAll(body); e != nil || !bytes.Equal(buf, data) {
+		t.Errorf("got %d bytes and error %v, want %d bytes", len(buf), e, len(data))
+	}
+}
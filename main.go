@@ -4,10 +4,13 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
 	"runtime"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/MindHunter86/NexusCloner/cloner"
@@ -50,26 +53,115 @@ func main() {
 		},
 		cli.DurationFlag{
 			Name:  "http-client-timeout",
-			Usage: "Internal HTTP client connection `TIMEOUT` (format: 1000ms, 1s)",
+			Usage: "API request `TIMEOUT` including the response reading (format: 1000ms, 1s). File transfers have their own timeouts.",
 			Value: 10 * time.Second,
 		},
+		cli.DurationFlag{
+			Name:  "http-connect-timeout",
+			Usage: "TCP connection `TIMEOUT`",
+			Value: 10 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "http-tls-timeout",
+			Usage: "TLS handshake `TIMEOUT`",
+			Value: 10 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "http-header-timeout",
+			Usage: "`TIMEOUT` of waiting for the response headers after the request sending",
+			Value: 30 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "http-idle-timeout",
+			Usage: "Download is interrupted (and resumed, if it's possible) if there is no received data for the `TIMEOUT`",
+			Value: 30 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "http-transfer-timeout",
+			Usage: "Base `TIMEOUT` of the file download or upload. It's increased by the file size with --http-transfer-min-rate. 0 disables the transfer timeout.",
+			Value: time.Minute,
+		},
+		cli.Int64Flag{
+			Name:  "http-transfer-min-rate",
+			Usage: "Min expected transfer `RATE` in bytes per second for the transfer timeout scaling. The lower bandwidth limit is used instead of it.",
+			Value: 64 * 1024,
+		},
 		cli.BoolFlag{
 			Name:  "http-client-insecure",
 			Usage: "Flag for TLS certificate verification disabling",
 		},
+		cli.IntFlag{
+			Name:  "http-retry-attempts",
+			Usage: "Max `count` of attempts for the failed HTTP requests (transport errors, 408, 429 and 5xx responses). Uploads are retried after the destination check only.",
+			Value: 3,
+		},
+		cli.DurationFlag{
+			Name:  "http-retry-delay",
+			Usage: "Initial `DELAY` between attempts, it's doubled on every attempt with random jitter. Retry-After header is used if it's longer.",
+			Value: time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "http-retry-max-delay",
+			Usage: "Max `DELAY` between attempts of the exponential backoff",
+			Value: 30 * time.Second,
+		},
+
+		// Limits
+		cli.Float64Flag{
+			Name:  "src-rate",
+			Usage: "Max `count` of requests per second to the source endpoint (0 is unlimited)",
+		},
+		cli.Float64Flag{
+			Name:  "dst-rate",
+			Usage: "Max `count` of requests per second to the destination endpoint (0 is unlimited)",
+		},
+		cli.Int64Flag{
+			Name:  "max-bandwidth",
+			Usage: "Max transfer `RATE` in bytes per second for every endpoint (0 is unlimited)",
+		},
+		cli.Int64Flag{
+			Name:  "src-max-bandwidth",
+			Usage: "Max transfer `RATE` in bytes per second for the source endpoint. It overrides --max-bandwidth.",
+		},
+		cli.Int64Flag{
+			Name:  "dst-max-bandwidth",
+			Usage: "Max transfer `RATE` in bytes per second for the destination endpoint. It overrides --max-bandwidth.",
+		},
 
 		// Queue settings
-		//
+		cli.IntFlag{
+			Name:  "repo-concurrency",
+			Usage: "Count of repositories which are synchronized concurrently with --all or --repo-regex",
+			Value: 4,
+		},
 
 		// System settings
 		cli.StringFlag{
 			Name:  "temp-path-prefix",
 			Usage: "Define prefix for temporary `directory`. If not defined, UNIX or WIN default will be used.",
 		},
+		cli.Int64Flag{
+			Name:  "max-staging-bytes",
+			Usage: "Max `size` of the temporary directory content. Missing assets are downloaded and uploaded in batches which are fit the size (0 is unlimited).",
+		},
 		cli.BoolFlag{
 			Name:  "temp-path-save",
 			Usage: "Flag for saving temp path content before program close. Flag for debugging only.",
 		},
+		cli.StringFlag{
+			Name:  "process-continue-directory",
+			Usage: "Use the `directory` for staging instead of the new temporary one. Partial files of the interrupted run are continued, the directory is not removed.",
+		},
+		cli.DurationFlag{
+			Name:  "shutdown-timeout",
+			Usage: "Time for in-flight transfers finishing after SIGINT or SIGTERM. Transfers are cancelled after the timeout.",
+			Value: 30 * time.Second,
+		},
+		cli.StringFlag{
+			Name:  "state-file",
+			Usage: "Progress state `file` which is written if the synchronization has been interrupted",
+			Value: "nexuscloner-state.json",
+		},
 
 		// Application options
 		cli.BoolFlag{
@@ -80,10 +172,68 @@ func main() {
 			Name:  "skip-download-errors",
 			Usage: "Continue synchronization process if missing assets download detected",
 		},
+		cli.StringFlag{
+			Name:  "cache-dir",
+			Usage: "Persistent asset cache `path`. Assets are found in the cache by their checksums, so they are downloaded once for all runs and repositories.",
+		},
+		cli.Int64Flag{
+			Name:  "cache-max-size",
+			Usage: "Max cache `size` in bytes. Least recently used assets are evicted if the cache is bigger (0 is unlimited).",
+		},
+		cli.BoolFlag{
+			Name:  "stream",
+			Usage: "Transfer assets from the source to the destination directly without temporary files. Failed assets are transferred with the temporary files.",
+		},
 		cli.BoolFlag{
 			Name:  "skip-upload",
 			Usage: "Skip upload after downloading missing assets. Flag for debugging only.",
 		},
+		cli.StringFlag{
+			Name:  "src-base-path",
+			Usage: "Context `path` of the source Nexus (e.g. /nexus). If not defined, it will be detected by /repository/ url segment.",
+		},
+		cli.StringFlag{
+			Name:  "dst-base-path",
+			Usage: "Context `path` of the destination Nexus (e.g. /nexus). If not defined, it will be detected by /repository/ url segment.",
+		},
+		cli.StringSliceFlag{
+			Name:  "download-url-rewrite",
+			Usage: "Rewrite asset download url `prefix` with the rule (format: https://public.example.com=http://nexus.internal:8081). Could be defined multiple times.",
+		},
+		cli.BoolFlag{
+			Name:  "download-url-rebase",
+			Usage: "Flag for rebasing asset download urls onto the source endpoint given in arguments",
+		},
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "Synchronize all hosted repositories of the source Nexus. Arguments are Nexus server urls in this mode.",
+		},
+		cli.StringFlag{
+			Name:  "repo-regex",
+			Usage: "Synchronize hosted repositories of the source Nexus matched by `regexp`. Arguments are Nexus server urls in this mode.",
+		},
+		cli.StringFlag{
+			Name:  "repo-rename",
+			Usage: "Destination repository name `template` for --all and --repo-regex. {repo} is replaced with the source repository name.",
+			Value: "{repo}",
+		},
+		cli.BoolFlag{
+			Name:  "warm-proxy",
+			Usage: "Request missing assets through the destination proxy repository for its cache warming instead of uploading",
+		},
+		cli.StringFlag{
+			Name:  "group-mode",
+			Usage: "Synchronization `mode` for the source group repository: flatten (all members to the destination repository) or members (every hosted member to the destination repository named by --repo-rename)",
+			Value: "flatten",
+		},
+		cli.BoolFlag{
+			Name:  "create-missing-repos",
+			Usage: "Create missing destination hosted repositories with the source repository configuration",
+		},
+		cli.StringSliceFlag{
+			Name:  "blob-store-map",
+			Usage: "Blob store `mapping` for --create-missing-repos (format: source-blobstore=destination-blobstore). Could be defined multiple times.",
+		},
 		cli.StringFlag{
 			Name:  "path-filter",
 			Usage: "Regexp value with `path` for syncing.",
@@ -96,7 +246,7 @@ func main() {
 	}).With().Timestamp().Logger().Hook(SeverityHook{})
 	zerolog.TimeFieldFormat = time.RFC3339Nano
 
-	app.Action = func(c *cli.Context) (e error) {
+	app.Before = func(c *cli.Context) (e error) {
 
 		if c.Int("verbose") < -1 || c.Int("verbose") > 5 {
 			log.Fatal().Msg("There is invalid data in verbose option. Option supports values for -1 to 5")
@@ -107,7 +257,95 @@ func main() {
 			zerolog.SetGlobalLevel(zerolog.Disabled)
 		}
 
-		return cloner.NewCloner(&log).Bootstrap(c) // Application starts here:
+		return
+	}
+
+	// the first signal stops the synchronization gracefully, the second one kills the process
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var signals = make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		var sig = <-signals
+		signal.Stop(signals)
+
+		log.Warn().Str("signal", sig.String()).Msg("Signal has been received. Stopping the synchronization, send it again for the immediate exit.")
+		cancel()
+	}()
+
+	app.Action = func(c *cli.Context) (e error) {
+		_, e = cloner.New(getOptions(c, c.Args(), &log)).Sync(ctx) // Application starts here:
+		return
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:      "config-clone",
+			Usage:     "Clone Nexus server configuration (blob stores, cleanup policies, routing rules, content selectors)",
+			ArgsUsage: "SOURCE_NEXUS_URL DESTINATION_NEXUS_URL",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "items",
+					Usage: "Comma separated `list` of configuration items for cloning",
+					Value: "blobstores,cleanup-policies,routing-rules,content-selectors",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Show the difference between servers without any changes",
+				},
+				cli.BoolFlag{
+					Name:  "update-existing",
+					Usage: "Update existing items which differ from the source (they are skipped by default)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return cloner.New(getOptions(c.Parent(), c.Args(), &log)).CloneConfig(ctx, &cloner.ConfigOptions{
+					Items:          c.String("items"),
+					DryRun:         c.Bool("dry-run"),
+					UpdateExisting: c.Bool("update-existing"),
+				})
+			},
+		},
+		{
+			Name:      "security-clone",
+			Usage:     "Clone Nexus security configuration (privileges, roles, local users)",
+			ArgsUsage: "SOURCE_NEXUS_URL DESTINATION_NEXUS_URL",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "items",
+					Usage: "Comma separated `list` of security items for cloning",
+					Value: "privileges,roles,users",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Show the difference between servers without any changes",
+				},
+				cli.BoolFlag{
+					Name:  "update-existing",
+					Usage: "Update existing items which differ from the source (they are skipped by default)",
+				},
+				cli.StringFlag{
+					Name:  "user-passwords",
+					Usage: "Passwords `mode` for missing users: skip (users are not created) or generate (random passwords are written to --user-passwords-file)",
+					Value: "skip",
+				},
+				cli.StringFlag{
+					Name:  "user-passwords-file",
+					Usage: "`File` for generated user passwords (format: userId:password)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				return cloner.New(getOptions(c.Parent(), c.Args(), &log)).CloneSecurity(ctx, &cloner.ConfigOptions{
+					Items:             c.String("items"),
+					DryRun:            c.Bool("dry-run"),
+					UpdateExisting:    c.Bool("update-existing"),
+					UserPasswords:     c.String("user-passwords"),
+					UserPasswordsFile: c.String("user-passwords-file"),
+				})
+			},
+		},
 	}
 
 	// sort.Sort(cli.FlagsByName(app.Flags))
@@ -118,6 +356,64 @@ func main() {
 	}
 }
 
+// getOptions returns the cloner options from the global flags and the endpoint arguments
+func getOptions(c *cli.Context, args cli.Args, log *zerolog.Logger) *cloner.Options {
+	return &cloner.Options{
+		Logger: log,
+
+		Source:      args.Get(0),
+		Destination: args.Get(1),
+
+		SrcBasePath:        c.String("src-base-path"),
+		DstBasePath:        c.String("dst-base-path"),
+		PathFilter:         c.String("path-filter"),
+		DownloadURLRewrite: c.StringSlice("download-url-rewrite"),
+		DownloadURLRebase:  c.Bool("download-url-rebase"),
+
+		All:                c.Bool("all"),
+		RepoRegex:          c.String("repo-regex"),
+		RepoRename:         c.String("repo-rename"),
+		RepoConcurrency:    c.Int("repo-concurrency"),
+		GroupMode:          c.String("group-mode"),
+		WarmProxy:          c.Bool("warm-proxy"),
+		CreateMissingRepos: c.Bool("create-missing-repos"),
+		BlobStoreMap:       c.StringSlice("blob-store-map"),
+
+		SkipDownload:       c.Bool("skip-download"),
+		SkipDownloadErrors: c.Bool("skip-download-errors"),
+		SkipUpload:         c.Bool("skip-upload"),
+		Stream:             c.Bool("stream"),
+		CacheDir:           c.String("cache-dir"),
+		CacheMaxSize:       c.Int64("cache-max-size"),
+
+		TempPathPrefix:           c.String("temp-path-prefix"),
+		TempPathSave:             c.Bool("temp-path-save"),
+		ProcessContinueDirectory: c.String("process-continue-directory"),
+		MaxStagingBytes:          c.Int64("max-staging-bytes"),
+
+		HTTPClientTimeout:   c.Duration("http-client-timeout"),
+		HTTPConnectTimeout:  c.Duration("http-connect-timeout"),
+		HTTPTLSTimeout:      c.Duration("http-tls-timeout"),
+		HTTPHeaderTimeout:   c.Duration("http-header-timeout"),
+		HTTPIdleTimeout:     c.Duration("http-idle-timeout"),
+		HTTPTransferTimeout: c.Duration("http-transfer-timeout"),
+		HTTPTransferMinRate: c.Int64("http-transfer-min-rate"),
+		HTTPClientInsecure:  c.Bool("http-client-insecure"),
+		HTTPRetryAttempts:   c.Int("http-retry-attempts"),
+		HTTPRetryDelay:      c.Duration("http-retry-delay"),
+		HTTPRetryMaxDelay:   c.Duration("http-retry-max-delay"),
+
+		SrcRate:         c.Float64("src-rate"),
+		DstRate:         c.Float64("dst-rate"),
+		MaxBandwidth:    c.Int64("max-bandwidth"),
+		SrcMaxBandwidth: c.Int64("src-max-bandwidth"),
+		DstMaxBandwidth: c.Int64("dst-max-bandwidth"),
+
+		ShutdownTimeout: c.Duration("shutdown-timeout"),
+		StateFile:       c.String("state-file"),
+	}
+}
+
 type SeverityHook struct{}
 
 func (h SeverityHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {